@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"DiskSizer/agent"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	agentListen      string
+	agentRoot        string
+	agentToken       string
+	agentAllowDelete bool
+)
+
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Serves scans of this machine to a remote disksizer",
+	Long: `Serves scan and list-children operations on the directories below --root,
+the working directory by default, over HTTP and JSON, so 'disksizer --agent
+ADDRESS' can browse this machine from another one. Requests must carry the
+token, which is taken from --token or DISKSIZER_AGENT_TOKEN, or generated and
+printed at startup. Clients can only delete files with --allow-delete.
+
+The protocol is not encrypted: listen on a Unix socket (unix:/path) or on
+localhost and forward it over SSH to reach it from another machine.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root, err := filepath.Abs(agentRoot)
+		if err != nil {
+			return err
+		}
+
+		token := agentToken
+		if token == "" {
+			token = os.Getenv("DISKSIZER_AGENT_TOKEN")
+		}
+		if token == "" {
+			secret := make([]byte, 16)
+			if _, err := rand.Read(secret); err != nil {
+				return err
+			}
+			token = hex.EncodeToString(secret)
+			fmt.Fprintf(os.Stderr, "Token: %s\n", token)
+		}
+
+		listener, err := agent.Listen(agentListen)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "Serving %s on %s\n", root, agentListen)
+		server := &http.Server{
+			Handler:      agent.NewServer(root, token, agentAllowDelete).Handler(),
+			ReadTimeout:  30 * time.Second,
+			WriteTimeout: 30 * time.Minute, // Scans of large trees take a while
+			IdleTimeout:  2 * time.Minute,
+		}
+		return server.Serve(listener)
+	},
+}
+
+func init() {
+	agentCmd.Flags().StringVar(&agentListen, "listen", "127.0.0.1:7070", "Address to listen on, host:port or unix:/path/to/socket")
+	agentCmd.Flags().StringVar(&agentRoot, "root", ".", "Only serve the directories below this path")
+	agentCmd.Flags().BoolVar(&agentAllowDelete, "allow-delete", false, "Let clients delete files and directories below the root")
+	agentCmd.Flags().StringVar(&agentToken, "token", "", "Token clients must send (default $DISKSIZER_AGENT_TOKEN or a random one)")
+	rootCmd.AddCommand(agentCmd)
+}
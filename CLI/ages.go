@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"DiskSizer/Utils"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	agesAccessTime bool
+	staleDays      int
+	staleTop       int
+)
+
+var agesCmd = &cobra.Command{
+	Use:   "ages <path>",
+	Short: "Shows disk usage by file age",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root, _, err := scanPath(args[0])
+		if err != nil {
+			return fmt.Errorf("error scanning path: %v", err)
+		}
+
+		title := "LAST MODIFIED"
+		if agesAccessTime {
+			title = "LAST ACCESSED"
+		}
+
+		stats := Utils.AgeBreakdown(root, time.Now(), agesAccessTime)
+		fmt.Printf("Age of files in %s (%s)\n\n", root.Path, Utils.FormatSize(root.Size))
+		return printUsageStats(title, stats, root.Size)
+	},
+}
+
+var staleCmd = &cobra.Command{
+	Use:   "stale <path>",
+	Short: "Lists files that have not been modified in a number of days",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if staleDays < 0 {
+			return fmt.Errorf("--days must not be negative")
+		}
+
+		root, _, err := scanPath(args[0])
+		if err != nil {
+			return fmt.Errorf("error scanning path: %v", err)
+		}
+
+		now := time.Now()
+		var stale []Utils.DirEntry
+		var staleSize int64
+		Utils.WalkEntries(root, func(e Utils.DirEntry, depth int) bool {
+			if Utils.IsStale(e, now, staleDays, agesAccessTime) {
+				stale = append(stale, e)
+				staleSize += e.Size
+			}
+			return true
+		})
+		sort.Slice(stale, func(i, j int) bool {
+			return stale[i].Size > stale[j].Size
+		})
+
+		kind := "modified"
+		if agesAccessTime {
+			kind = "accessed"
+		}
+		share := 0.0
+		if root.Size > 0 {
+			share = float64(staleSize) / float64(root.Size) * 100
+		}
+		fmt.Printf("%d files (%s, %.1f%% of %s) not %s in %d days\n\n",
+			len(stale), Utils.FormatSize(staleSize), share, root.Path, kind, staleDays)
+
+		if staleTop > 0 && len(stale) > staleTop {
+			stale = stale[:staleTop]
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "SIZE\tLAST %s\tPATH\t\n", strings.ToUpper(kind))
+		for _, e := range stale {
+			when := e.ModTime
+			if agesAccessTime {
+				when = e.AccessTime
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t\n", Utils.FormatSize(e.Size), when.Format("2006-01-02"), e.Path)
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	agesCmd.Flags().BoolVar(&agesAccessTime, "atime", false, "Use the last access time instead of the modification time")
+	staleCmd.Flags().BoolVar(&agesAccessTime, "atime", false, "Use the last access time instead of the modification time")
+	staleCmd.Flags().IntVar(&staleDays, "days", 90, "Minimum number of days since the last change")
+	staleCmd.Flags().IntVar(&staleTop, "top", 20, "Only list the N largest files (0 lists all)")
+	rootCmd.AddCommand(agesCmd)
+	rootCmd.AddCommand(staleCmd)
+}
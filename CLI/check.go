@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"DiskSizer/Utils"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	checkRulesFile string
+	checkFormat    string
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Checks disk usage against the limits of a rules file",
+	Long: `Checks partition usage, directory sizes and directory growth since the last
+'disksizer snapshot' against the limits of a YAML rules file:
+
+  history: ~/.local/share/disksizer/history.jsonl  # optional
+  rules:
+    - partition: /
+      maxUsedPercent: 90
+    - name: home
+      path: ~/
+      maxSize: 50GB
+      maxGrowth: 1GB
+
+Exits with a non-zero status if any limit is exceeded or could not be checked.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if checkFormat != "text" && checkFormat != "json" {
+			return fmt.Errorf("invalid --format value %q, must be text or json", checkFormat)
+		}
+
+		config, err := Utils.LoadCheckConfig(checkRulesFile)
+		if err != nil {
+			return fmt.Errorf("error loading rules: %v", err)
+		}
+
+		results := Utils.RunChecks(config)
+		failed := 0
+		for _, result := range results {
+			if !result.OK {
+				failed++
+			}
+		}
+
+		if checkFormat == "json" {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(results); err != nil {
+				return err
+			}
+		} else {
+			printCheckResults(results)
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d of %d checks failed", failed, len(results))
+		}
+		return nil
+	},
+}
+
+// printCheckResults prints one line per check with its status
+func printCheckResults(results []Utils.CheckResult) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, result := range results {
+		status := "OK"
+		switch {
+		case result.Error != "":
+			status = "ERROR"
+		case !result.OK:
+			status = "FAIL"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t\n", status, result.Rule, result.Kind, result.Message)
+	}
+	w.Flush()
+}
+
+func init() {
+	checkCmd.Flags().StringVar(&checkRulesFile, "rules", Utils.DefaultCheckFile(), "YAML file with the rules to check")
+	checkCmd.Flags().StringVar(&checkFormat, "format", "text", "Output format: text or json")
+	rootCmd.AddCommand(checkCmd)
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"DiskSizer/app"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var configFormat string
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Shows the settings of the config file",
+	Long: `DiskSizer reads its settings from $XDG_CONFIG_HOME/disksizer/config.yaml,
+or the file given by --config or $DISKSIZER_CONFIG:
+
+  scan:
+    workers: 8          # 0 for one per CPU
+    skipHidden: false
+  exclude: [node_modules, /proc, "~/Library/Mobile Documents"]
+  sort: size            # size, name or modified
+  units: binary         # binary (KB), iec (KiB) or decimal (kB)
+  theme: dark           # dark, light, high-contrast or mono
+  colors:               # colour names or #rrggbb by element
+    directory: "#87afff"
+  keymap: vim           # default, or vim for hjkl, gg and G
+  keys:                 # action: key or [keys], replacing the keymap's
+    quit: [q, ctrl-c]
+    compare: g c        # keys separated by spaces are typed in sequence
+
+The elements of colors are header, footer, title, heading, highlight, label,
+link, directory, file, archive, info, cached, muted, warning, error,
+success, reclaim and git. For highlight and the reclaim and git badges the
+colour is that of the background. The mono theme, without colours, is used
+whenever NO_COLOR is set.
+
+Press ? in the tree for the names and keys of the actions.
+
+The DISKSIZER_TREE_SORT, DISKSIZER_UNITS, DISKSIZER_SCAN_WORKERS,
+DISKSIZER_EXCLUDE, DISKSIZER_THEME and DISKSIZER_KEYMAP environment variables
+override the file, and the --tree-sort, --units, --scan-workers, --exclude,
+--theme and --keymap flags override both.`,
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Prints the effective settings",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := app.ApplyConfig(appConfig); err != nil {
+			return fmt.Errorf("error loading config: %v", err)
+		}
+
+		switch configFormat {
+		case "json":
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(appConfig)
+		case "yaml":
+			if _, err := os.Stat(configFile); errors.Is(err, fs.ErrNotExist) {
+				fmt.Printf("# %s does not exist, these are the defaults\n", configFile)
+			} else {
+				fmt.Printf("# Read from %s\n", configFile)
+			}
+			encoder := yaml.NewEncoder(os.Stdout)
+			encoder.SetIndent(2)
+			if err := encoder.Encode(appConfig); err != nil {
+				return err
+			}
+			return encoder.Close()
+		}
+		return fmt.Errorf("invalid --format value %q, must be yaml or json", configFormat)
+	},
+}
+
+func init() {
+	configShowCmd.Flags().StringVar(&configFormat, "format", "yaml", "Output format: yaml or json")
+	configCmd.AddCommand(configShowCmd)
+	rootCmd.AddCommand(configCmd)
+}
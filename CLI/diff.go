@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"DiskSizer/Utils"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffTop      int
+	diffMinDelta int64
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <old.json> <new.json>",
+	Short: "Shows what was added, removed or resized between two scan snapshots",
+	Long: `Shows what was added, removed or resized between two snapshots written by
+'disksizer scan --format json', sorted by absolute growth.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		oldSnapshot, err := Utils.LoadSnapshot(args[0])
+		if err != nil {
+			return fmt.Errorf("error loading %s: %v", args[0], err)
+		}
+		newSnapshot, err := Utils.LoadSnapshot(args[1])
+		if err != nil {
+			return fmt.Errorf("error loading %s: %v", args[1], err)
+		}
+
+		fmt.Printf("%s: %s (%s) -> %s (%s), %s\n\n", newSnapshot.Path,
+			Utils.FormatSize(oldSnapshot.Root.Size), oldSnapshot.ScannedAt.Format("2006-01-02 15:04"),
+			Utils.FormatSize(newSnapshot.Root.Size), newSnapshot.ScannedAt.Format("2006-01-02 15:04"),
+			Utils.FormatDelta(newSnapshot.Root.Size-oldSnapshot.Root.Size))
+
+		return printDiff(Utils.DiffTrees(oldSnapshot.Root, newSnapshot.Root))
+	},
+}
+
+// printDiff prints the changes that pass the --min-delta and --top flags
+func printDiff(diffs []Utils.DiffEntry) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "CHANGE\tDELTA\tOLD\tNEW\tPATH\t\n")
+
+	shown := 0
+	for _, d := range diffs {
+		if diffTop > 0 && shown >= diffTop {
+			break
+		}
+		delta := d.Delta()
+		if delta < 0 {
+			delta = -delta
+		}
+		if delta < diffMinDelta {
+			continue
+		}
+
+		path := d.Path
+		if d.IsDir {
+			path += string(os.PathSeparator)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t\n", d.Kind, Utils.FormatDelta(d.Delta()),
+			Utils.FormatSize(d.OldSize), Utils.FormatSize(d.NewSize), path)
+		shown++
+	}
+	return w.Flush()
+}
+
+func init() {
+	diffCmd.Flags().IntVar(&diffTop, "top", 50, "Only show the N largest changes (0 shows all)")
+	diffCmd.Flags().Int64Var(&diffMinDelta, "min-delta", 1, "Hide changes smaller than this many bytes")
+	rootCmd.AddCommand(diffCmd)
+}
@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"DiskSizer/Utils"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	dupesMinSize   int64
+	dupesWorkers   int
+	dupesDelete    bool
+	dupesHardLink  bool
+	dupesTopGroups int
+)
+
+var dupesCmd = &cobra.Command{
+	Use:   "dupes <path>",
+	Short: "Finds duplicate files and optionally deletes or hard-links them",
+	Long: `Finds duplicate files by comparing sizes, then a hash of the first bytes
+and finally a hash of the full contents.
+
+With --delete or --hardlink the first file of every group (in path order) is
+kept and the other copies are deleted or replaced with hard links to it.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if dupesDelete && dupesHardLink {
+			return fmt.Errorf("--delete and --hardlink cannot be used together")
+		}
+
+		root, _, err := scanPath(args[0])
+		if err != nil {
+			return fmt.Errorf("error scanning path: %v", err)
+		}
+
+		groups := Utils.FindDuplicates(root, dupesMinSize, dupesWorkers)
+
+		var wasted int64
+		for _, group := range groups {
+			wasted += group.Wasted()
+		}
+		fmt.Printf("%d duplicate groups in %s, %s wasted\n", len(groups), root.Path, Utils.FormatSize(wasted))
+
+		shown := groups
+		if dupesTopGroups > 0 && len(shown) > dupesTopGroups {
+			shown = shown[:dupesTopGroups]
+		}
+		for _, group := range shown {
+			fmt.Printf("\n%d copies of %s (%s wasted)\n", len(group.Paths), Utils.FormatSize(group.Size), Utils.FormatSize(group.Wasted()))
+			for _, path := range group.Paths {
+				fmt.Printf("  %s\n", path)
+			}
+		}
+
+		if !dupesDelete && !dupesHardLink {
+			return nil
+		}
+
+		var reclaimed int64
+		for _, group := range groups {
+			keep := group.Paths[0]
+			var changed, skipped []string
+			if dupesDelete {
+				changed, skipped, err = Utils.DeleteDuplicates(group, keep)
+			} else {
+				changed, skipped, err = Utils.HardLinkDuplicates(group, keep)
+			}
+			reclaimed += group.Size * int64(len(changed))
+			for _, path := range skipped {
+				fmt.Printf("Kept %s, it no longer matches %s\n", path, keep)
+			}
+			if err != nil {
+				return fmt.Errorf("error resolving duplicates of %s: %v", keep, err)
+			}
+		}
+		fmt.Printf("\nReclaimed %s\n", Utils.FormatSize(reclaimed))
+		return nil
+	},
+}
+
+func init() {
+	dupesCmd.Flags().Int64Var(&dupesMinSize, "min-size", 1, "Ignore files smaller than this many bytes")
+	dupesCmd.Flags().IntVar(&dupesWorkers, "workers", 0, "Number of files hashed in parallel (0 uses the number of CPUs)")
+	dupesCmd.Flags().BoolVar(&dupesDelete, "delete", false, "Delete all but the first copy of every group")
+	dupesCmd.Flags().BoolVar(&dupesHardLink, "hardlink", false, "Replace all but the first copy of every group with hard links")
+	dupesCmd.Flags().IntVar(&dupesTopGroups, "top", 20, "Only list the N groups wasting the most space (0 lists all)")
+	rootCmd.AddCommand(dupesCmd)
+}
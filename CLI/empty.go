@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"DiskSizer/Utils"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var emptyDelete bool
+
+var emptyCmd = &cobra.Command{
+	Use:   "empty <path>",
+	Short: "Lists empty directories and zero-byte files",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root, _, err := scanPath(args[0])
+		if err != nil {
+			return fmt.Errorf("error scanning path: %v", err)
+		}
+
+		report := Utils.FindEmpty(root)
+
+		fmt.Printf("Empty directories: %d\n", report.DirCount)
+		for _, path := range report.Dirs {
+			fmt.Printf("  %s\n", path)
+		}
+		fmt.Printf("\nZero-byte files: %d\n", len(report.Files))
+		for _, path := range report.Files {
+			fmt.Printf("  %s\n", path)
+		}
+
+		if !emptyDelete {
+			return nil
+		}
+
+		dirs, files, err := Utils.RemoveEmpty(report)
+		fmt.Printf("\nRemoved %d directories and %d files\n", dirs, files)
+		return err
+	},
+}
+
+func init() {
+	emptyCmd.Flags().BoolVar(&emptyDelete, "delete", false, "Delete the listed directories and files")
+	rootCmd.AddCommand(emptyCmd)
+}
@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"DiskSizer/Utils"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyFile    string
+	snapshotDepth  int
+	historyColumns int
+	historySort    string
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot <path>",
+	Short: "Records the sizes of the top-level directories in the history",
+	Long: `Scans a directory and appends the sizes of the directories up to --depth
+levels below it to the history file, so 'disksizer history' can show how they
+grow over time. Meant to be run regularly, for example from cron.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if snapshotDepth < 0 {
+			return fmt.Errorf("invalid --depth value %d", snapshotDepth)
+		}
+
+		root, _, err := scanPath(args[0])
+		if err != nil {
+			return fmt.Errorf("error scanning path: %v", err)
+		}
+
+		record := Utils.NewHistoryRecord(root, snapshotDepth)
+		if err := Utils.AppendHistory(historyFile, record); err != nil {
+			return fmt.Errorf("error writing history: %v", err)
+		}
+
+		fmt.Printf("Recorded %d directories of %s (%s) in %s\n", len(record.Sizes), root.Path, Utils.FormatSize(root.Size), historyFile)
+		return nil
+	},
+}
+
+var historyCmd = &cobra.Command{
+	Use:   "history <path>",
+	Short: "Shows how the directories of a path grew across recorded snapshots",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if historySort != "path" && historySort != "growth" {
+			return fmt.Errorf("invalid --sort value %q, must be path or growth", historySort)
+		}
+
+		absPath, err := filepath.Abs(args[0])
+		if err != nil {
+			return err
+		}
+
+		records, err := Utils.LoadHistory(historyFile, absPath)
+		if err != nil {
+			return fmt.Errorf("error reading history: %v", err)
+		}
+		if len(records) == 0 {
+			return fmt.Errorf("no snapshots of %s in %s, record one with 'disksizer snapshot'", absPath, historyFile)
+		}
+
+		fmt.Printf("%s: %d snapshots from %s to %s\n\n", absPath, len(records),
+			records[0].ScannedAt.Format("2006-01-02 15:04"), records[len(records)-1].ScannedAt.Format("2006-01-02 15:04"))
+		return printHistory(records)
+	},
+}
+
+// printHistory prints one row per directory with its size in the last
+// snapshots, its total change and its growth per day
+func printHistory(records []Utils.HistoryRecord) error {
+	trends := Utils.HistoryTrends(records)
+	if historySort == "growth" {
+		sort.SliceStable(trends, func(i, j int) bool {
+			return Utils.GrowthPerDay(trends[i], records) > Utils.GrowthPerDay(trends[j], records)
+		})
+	}
+
+	// Only the most recent snapshots get a column of their own
+	from := 0
+	if historyColumns > 0 && len(records) > historyColumns {
+		from = len(records) - historyColumns
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "PATH\t")
+	for _, record := range records[from:] {
+		fmt.Fprintf(w, "%s\t", record.ScannedAt.Format("01-02 15:04"))
+	}
+	fmt.Fprintf(w, "CHANGE\tPER DAY\t\n")
+
+	for _, trend := range trends {
+		fmt.Fprintf(w, "%s\t", trend.Path)
+		for _, size := range trend.Sizes[from:] {
+			if size < 0 {
+				fmt.Fprintf(w, "-\t")
+			} else {
+				fmt.Fprintf(w, "%s\t", Utils.FormatSize(size))
+			}
+		}
+
+		first, _ := trend.First()
+		last, _ := trend.Last()
+		perDay := Utils.GrowthPerDay(trend, records)
+		fmt.Fprintf(w, "%s\t%s\t\n", Utils.FormatDelta(last-first), Utils.FormatDelta(int64(perDay)))
+	}
+	return w.Flush()
+}
+
+func init() {
+	snapshotCmd.Flags().StringVar(&historyFile, "history", Utils.DefaultHistoryFile(), "History file to append to")
+	snapshotCmd.Flags().IntVar(&snapshotDepth, "depth", 2, "Record directories up to this many levels below the path")
+	historyCmd.Flags().StringVar(&historyFile, "history", Utils.DefaultHistoryFile(), "History file to read")
+	historyCmd.Flags().IntVar(&historyColumns, "last", 5, "Show the sizes of the last N snapshots (0 shows all)")
+	historyCmd.Flags().StringVar(&historySort, "sort", "path", "Sort by path or growth")
+	rootCmd.AddCommand(snapshotCmd)
+	rootCmd.AddCommand(historyCmd)
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"DiskSizer/Utils"
+	"DiskSizer/app"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var imagesFormat string
+
+var imagesCmd = &cobra.Command{
+	Use:   "images <layout dir | image tarball>",
+	Short: "Shows the space container images and their layers take up",
+	Long: `Reads the images of an OCI image layout directory or of a tarball written by
+'docker save' (.tar or .tar.gz) from disk and shows how much space each
+image takes up on its own and how much it shares with other images.
+
+By default the images are shown in the tree view: a directory per image
+holding the layers only it uses, i.e. the space removing it frees, and a
+(shared) directory with the layers used by several images. --format text
+or json prints a report instead.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if imagesFormat != "tree" && imagesFormat != "text" && imagesFormat != "json" {
+			return fmt.Errorf("invalid --format value %q, must be tree, text or json", imagesFormat)
+		}
+
+		path, err := filepath.Abs(args[0])
+		if err != nil {
+			return err
+		}
+		report, err := Utils.ReadImages(path)
+		if err != nil {
+			return err
+		}
+
+		switch imagesFormat {
+		case "json":
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			encoder.SetEscapeHTML(false)
+			return encoder.Encode(report)
+		case "text":
+			return printImageReport(report)
+		}
+
+		fsys, err := report.FileSystem()
+		if err != nil {
+			return err
+		}
+		var processedSize int64
+		root, skipped, err := Utils.ScanFileSystem(fsys, path, &processedSize)
+		if err != nil {
+			return err
+		}
+		root.Name = filepath.Base(path)
+		app.OpenSnapshot(Utils.NewSnapshot(root, skipped), "container images in "+path)
+		app.StartApp("")
+		return nil
+	},
+}
+
+func init() {
+	imagesCmd.Flags().StringVar(&imagesFormat, "format", "tree", "Show the images in the tree view, or print them as text or json")
+	rootCmd.AddCommand(imagesCmd)
+}
+
+// printImageReport prints the images and the layers they share
+func printImageReport(report Utils.ImageReport) error {
+	fmt.Printf("Images in %s (%s, %s shared)\n\n", report.Path, Utils.FormatSize(report.Total), Utils.FormatSize(report.Shared))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "IMAGE\tSIZE\tUNIQUE\tSHARED\tBLOBS\tTAGS\t\n")
+	for _, image := range report.Images {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\t\n", image.Name, Utils.FormatSize(image.Size),
+			Utils.FormatSize(image.Unique), Utils.FormatSize(image.Shared), len(image.Blobs), strings.Join(image.Tags, ", "))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if report.Shared == 0 {
+		return nil
+	}
+	fmt.Println()
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "SHARED BLOB\tKIND\tSIZE\tIMAGES\t\n")
+	for _, blob := range report.Blobs {
+		if len(blob.Images) > 1 {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t\n", blob.Digest, blob.Kind, Utils.FormatSize(blob.Size), strings.Join(blob.Images, ", "))
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"DiskSizer/Utils"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	metricsListen   string
+	metricsInterval time.Duration
+	metricsDepth    int
+)
+
+var serveMetricsCmd = &cobra.Command{
+	Use:   "serve-metrics <path>...",
+	Short: "Serves directory sizes and partition usage as Prometheus metrics",
+	Long: `Rescans the given paths every --interval and serves the sizes and file
+counts of the directories up to --depth levels below them, the scan durations
+and errors, and the usage of the mounted partitions in the Prometheus text
+format at /metrics.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if metricsInterval < time.Second {
+			return fmt.Errorf("invalid --interval value %s, must be at least 1s", metricsInterval)
+		}
+		if metricsDepth < 0 {
+			return fmt.Errorf("invalid --depth value %d", metricsDepth)
+		}
+
+		var paths []string
+		for _, arg := range args {
+			absPath, err := filepath.Abs(arg)
+			if err != nil {
+				return err
+			}
+			if !slices.Contains(paths, absPath) {
+				paths = append(paths, absPath)
+			}
+		}
+
+		exporter := Utils.NewMetricsExporter(paths, metricsDepth)
+		go exporter.Run(metricsInterval, nil)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+			if err := exporter.WriteMetrics(w); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing metrics: %v\n", err)
+			}
+		})
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/" {
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprintln(w, `<html><body><a href="/metrics">Metrics</a></body></html>`)
+		})
+
+		fmt.Fprintf(os.Stderr, "Serving metrics of %d paths on %s/metrics, rescanning every %s\n", len(paths), metricsListen, metricsInterval)
+		return http.ListenAndServe(metricsListen, mux)
+	},
+}
+
+func init() {
+	serveMetricsCmd.Flags().StringVar(&metricsListen, "listen", ":9105", "Address to serve the metrics on")
+	serveMetricsCmd.Flags().DurationVar(&metricsInterval, "interval", 5*time.Minute, "Time between rescans")
+	serveMetricsCmd.Flags().IntVar(&metricsDepth, "depth", 2, "Report directories up to this many levels below each path")
+	rootCmd.AddCommand(serveMetricsCmd)
+}
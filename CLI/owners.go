@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"DiskSizer/Utils"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	ownersBy   string
+	ownersSort string
+	ownersTop  int
+)
+
+var ownersCmd = &cobra.Command{
+	Use:   "owners <path>",
+	Short: "Shows disk usage per user or group",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if ownersBy != "user" && ownersBy != "group" {
+			return fmt.Errorf("invalid --by value %q, must be user or group", ownersBy)
+		}
+
+		root, _, err := scanPath(args[0])
+		if err != nil {
+			return fmt.Errorf("error scanning path: %v", err)
+		}
+
+		byUser, byGroup := Utils.OwnerBreakdown(root)
+		stats, title := byUser, "USER"
+		if ownersBy == "group" {
+			stats, title = byGroup, "GROUP"
+		}
+		Utils.SortUsageStats(stats, ownersSort)
+		if ownersTop > 0 && len(stats) > ownersTop {
+			stats = stats[:ownersTop]
+		}
+
+		fmt.Printf("Owners in %s (%s)\n\n", root.Path, Utils.FormatSize(root.Size))
+		return printUsageStats(title, stats, root.Size)
+	},
+}
+
+func init() {
+	ownersCmd.Flags().StringVar(&ownersBy, "by", "user", "Group by user or group")
+	ownersCmd.Flags().StringVar(&ownersSort, "sort", Utils.SortBySize, "Sort by size, count or name")
+	ownersCmd.Flags().IntVar(&ownersTop, "top", 0, "Only show the first N rows (0 shows all)")
+	rootCmd.AddCommand(ownersCmd)
+}
+
+// printUsageStats prints a breakdown table with each row's share of total
+func printUsageStats(title string, stats []Utils.UsageStat, total int64) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\tFILES\tSIZE\tSHARE\t\n", title)
+	for _, stat := range stats {
+		share := 0.0
+		if total > 0 {
+			share = float64(stat.Size) / float64(total) * 100
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%.1f%%\t\n", stat.Key, stat.Count, Utils.FormatSize(stat.Size), share)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"DiskSizer/Utils"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	reclaimRulesFile string
+	reclaimClean     bool
+)
+
+var reclaimCmd = &cobra.Command{
+	Use:   "reclaim <path>",
+	Short: "Finds caches and build output whose space can be reclaimed",
+	Long: `Finds well-known caches and build output below a path, such as Go build and
+module caches, node_modules, ~/.cache, __pycache__, Rust and Maven target
+directories, .gradle, package manager caches and old journal logs, and shows
+how much space each rule could reclaim.
+
+More rules can be added, and built-in ones replaced or disabled by name, in
+a YAML rules file:
+
+  rules:
+    - name: bazel-cache
+      paths: [~/.cache/bazel]
+    - name: terraform-plugins
+      names: [.terraform]
+      markers: [main.tf]
+    - name: node_modules
+      disabled: true`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rules, err := Utils.LoadReclaimRules(reclaimRulesFile)
+		if err != nil {
+			return fmt.Errorf("error loading rules: %v", err)
+		}
+
+		root, _, err := scanPath(args[0])
+		if err != nil {
+			return fmt.Errorf("error scanning path: %v", err)
+		}
+
+		matches := Utils.FindReclaimable(root, rules)
+		var total int64
+		for _, match := range matches {
+			total += match.Size
+		}
+
+		fmt.Printf("Reclaimable in %s: %s\n\n", root.Path, Utils.FormatSize(total))
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "RULE\tDIRECTORIES\tSIZE\t\n")
+		for _, stat := range Utils.ReclaimTotals(matches) {
+			fmt.Fprintf(w, "%s\t%d\t%s\t\n", stat.Key, stat.Count, Utils.FormatSize(stat.Size))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		fmt.Println()
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "SIZE\tRULE\tPATH\t\n")
+		for _, match := range matches {
+			fmt.Fprintf(w, "%s\t%s\t%s\t\n", Utils.FormatSize(match.Size), match.Rule, match.Path)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		if !reclaimClean {
+			return nil
+		}
+		cleaned, err := Utils.CleanReclaimable(rules, matches)
+		fmt.Printf("\nCleaned %d of %d directories\n", cleaned, len(matches))
+		return err
+	},
+}
+
+func init() {
+	reclaimCmd.Flags().StringVar(&reclaimRulesFile, "rules", Utils.DefaultReclaimFile(), "YAML file with additional rules")
+	reclaimCmd.Flags().BoolVar(&reclaimClean, "clean", false, "Delete the listed directories, or run the cleanup command of their rule")
+	rootCmd.AddCommand(reclaimCmd)
+}
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"DiskSizer/Utils"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	repoTop    int
+	repoFormat string
+)
+
+var repoCmd = &cobra.Command{
+	Use:   "repo [path]",
+	Short: "Breaks down the space of a git repository",
+	Long: `Shows how the space of a git repository splits into .git and the working
+tree, how much of the working tree is tracked, untracked or ignored, and the
+largest objects in its packfiles. The index, .gitignore files and packfiles
+are read from disk, git itself is not needed.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if repoFormat != "text" && repoFormat != "json" {
+			return fmt.Errorf("invalid --format value %q, must be text or json", repoFormat)
+		}
+
+		path := "."
+		if len(args) > 0 {
+			path = args[0]
+		}
+		root, _, err := scanPath(path)
+		if err != nil {
+			return fmt.Errorf("error scanning path: %v", err)
+		}
+
+		repoRoot, found := Utils.FindGitRepo(root.Path)
+		if !found {
+			return fmt.Errorf("%s is not in a git repository", root.Path)
+		}
+		if repoRoot != root.Path {
+			if root, _, err = scanPath(repoRoot); err != nil {
+				return fmt.Errorf("error scanning path: %v", err)
+			}
+		}
+
+		repo, err := Utils.OpenGitRepo(repoRoot)
+		if err != nil {
+			return err
+		}
+		report, err := repo.Report(root, repoTop)
+		if err != nil {
+			return err
+		}
+
+		if repoFormat == "json" {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(report)
+		}
+		return printGitReport(report)
+	},
+}
+
+func init() {
+	repoCmd.Flags().IntVar(&repoTop, "top", 10, "Number of paths and objects to list")
+	repoCmd.Flags().StringVar(&repoFormat, "format", "text", "Output format: text or json")
+	rootCmd.AddCommand(repoCmd)
+}
+
+// printGitReport prints the breakdown of a repository
+func printGitReport(report Utils.GitReport) error {
+	workTree := report.Tracked.Size + report.Untracked.Size + report.Ignored.Size
+	fmt.Printf("Repository %s (%s)\n\n", report.Root, Utils.FormatSize(report.GitSize+workTree))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "PART\tFILES\tSIZE\t\n")
+	fmt.Fprintf(w, ".git\t\t%s\t\n", Utils.FormatSize(report.GitSize))
+	fmt.Fprintf(w, "  packfiles\t\t%s\t\n", Utils.FormatSize(report.PackSize))
+	fmt.Fprintf(w, "  loose objects\t\t%s\t\n", Utils.FormatSize(report.LooseSize))
+	fmt.Fprintf(w, "working tree\t%d\t%s\t\n", report.Tracked.Files+report.Untracked.Files+report.Ignored.Files, Utils.FormatSize(workTree))
+	fmt.Fprintf(w, "  tracked\t%d\t%s\t\n", report.Tracked.Files, Utils.FormatSize(report.Tracked.Size))
+	fmt.Fprintf(w, "  untracked\t%d\t%s\t\n", report.Untracked.Files, Utils.FormatSize(report.Untracked.Size))
+	fmt.Fprintf(w, "  ignored\t%d\t%s\t\n", report.Ignored.Files, Utils.FormatSize(report.Ignored.Size))
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	for _, section := range []struct {
+		title string
+		stats []Utils.UsageStat
+	}{{"LARGEST IGNORED", report.TopIgnored}, {"LARGEST UNTRACKED", report.TopUntracked}} {
+		if len(section.stats) == 0 {
+			continue
+		}
+		fmt.Println()
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "%s\tFILES\tSIZE\t\n", section.title)
+		for _, stat := range section.stats {
+			fmt.Fprintf(w, "%s\t%d\t%s\t\n", stat.Key, stat.Count, Utils.FormatSize(stat.Size))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if len(report.LargeObjects) == 0 {
+		return nil
+	}
+	fmt.Println()
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "OBJECT\tTYPE\tPACKED\tSIZE\tPATH\t\n")
+	for _, object := range report.LargeObjects {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t\n", object.ID[:12], object.Type,
+			Utils.FormatSize(object.PackedSize), Utils.FormatSize(object.Size), object.Path)
+	}
+	return w.Flush()
+}
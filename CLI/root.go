@@ -1,20 +1,244 @@
 package cli
 
 import (
+	"DiskSizer/Utils"
+	"DiskSizer/agent"
+	"DiskSizer/app"
 	"fmt"
 	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
 
+var (
+	enableProfiling bool
+	cpuProfile      *os.File
+	agentAddress    string
+	agentAuth       string
+	snapshotFile    string
+	remoteCommand   string
+
+	// The config file and the flags overriding it
+	configFile    string
+	appConfig     Utils.Config
+	configSort    string
+	configUnits   string
+	configWorkers int
+	configExclude []string
+	configKeymap  string
+	configTheme   string
+)
+
 var rootCmd = &cobra.Command{
-    Use:   "DiskSizer",
-    Short: "DiskSizer is a CLI tool for disk usage analysis",
+	Use:   "disksizer [path | ssh://[user@]host[:port]/path]",
+	Short: "DiskSizer is a CLI tool for disk usage analysis",
+	Long: `DiskSizer is a CLI tool for disk usage analysis.
+
+Given an ssh:// URL, it runs 'disksizer scan --format json' on the remote
+machine through the local ssh binary and shows the result in offline view
+mode, as does --snapshot for a saved scan.
+
+Settings are read from $XDG_CONFIG_HOME/disksizer/config.yaml, or the file
+given by --config or $DISKSIZER_CONFIG. DISKSIZER_* environment variables
+override the file, and flags override both. 'disksizer config show' prints
+the effective settings.`,
+	Args:          cobra.MaximumNArgs(1),
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadConfig(cmd); err != nil {
+			return err
+		}
+		if enableProfiling {
+			return startProfiling()
+		}
+		return nil
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		if enableProfiling {
+			stopProfiling()
+		}
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var startPath string
+		if len(args) > 0 {
+			startPath = args[0]
+		}
+
+		rules, err := Utils.LoadReclaimRules(Utils.DefaultReclaimFile())
+		if err != nil {
+			return fmt.Errorf("error loading reclaim rules: %v", err)
+		}
+		app.SetReclaimRules(rules)
+		if err := app.ApplyConfig(appConfig); err != nil {
+			return fmt.Errorf("error loading config: %v", err)
+		}
+
+		// Show a remote or saved scan, or browse an agent, instead of the
+		// local disks
+		switch {
+		case strings.HasPrefix(startPath, "ssh://"):
+			if agentAddress != "" || snapshotFile != "" {
+				return fmt.Errorf("ssh:// targets cannot be combined with --agent or --snapshot")
+			}
+			target, err := Utils.ParseSSHTarget(startPath)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Scanning %s over ssh...\n", target)
+			snapshot, err := Utils.ScanOverSSH(target, remoteCommand, os.Stderr)
+			if err != nil {
+				return err
+			}
+			app.OpenSnapshot(snapshot, target.String())
+			startPath = ""
+		case snapshotFile != "":
+			snapshot, err := Utils.LoadSnapshot(snapshotFile)
+			if err != nil {
+				return fmt.Errorf("error loading %s: %v", snapshotFile, err)
+			}
+			app.OpenSnapshot(snapshot, snapshotFile)
+		case agentAddress != "":
+			token := agentAuth
+			if token == "" {
+				token = os.Getenv("DISKSIZER_AGENT_TOKEN")
+			}
+			if err := app.UseAgent(agent.NewClient(agentAddress, token)); err != nil {
+				return err
+			}
+		}
+
+		// Start the application
+		app.StartApp(startPath)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&enableProfiling, "profile", false, "Enable CPU profiling")
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", Utils.DefaultConfigFile(), "Config file")
+	rootCmd.PersistentFlags().StringVar(&configSort, "tree-sort", "", "Order of the tree: size, name or modified (default from config)")
+	rootCmd.PersistentFlags().StringVar(&configUnits, "units", "", "Size units: binary, iec or decimal (default from config)")
+	rootCmd.PersistentFlags().IntVar(&configWorkers, "scan-workers", 0, "Goroutines per directory scanned in parallel, 0 for one per CPU (default from config)")
+	rootCmd.PersistentFlags().StringVar(&configTheme, "theme", "", "Colours: dark, light, high-contrast or mono (default from config, mono if $NO_COLOR is set)")
+	rootCmd.PersistentFlags().StringVar(&configKeymap, "keymap", "", "Keys of the tree: default or vim (default from config)")
+	rootCmd.PersistentFlags().StringArrayVar(&configExclude, "exclude", nil, "Leave names or paths matching this glob pattern out of scans, in addition to the config")
+	rootCmd.Flags().StringVar(&agentAddress, "agent", "", "Browse the agent at this address (host:port or unix:/path) instead of the local disks")
+	rootCmd.Flags().StringVar(&agentAuth, "token", "", "Token of the agent (default $DISKSIZER_AGENT_TOKEN)")
+	rootCmd.Flags().StringVar(&snapshotFile, "snapshot", "", "Show a scan saved with 'disksizer scan --format json' in offline view mode")
+	rootCmd.Flags().StringVar(&remoteCommand, "remote-command", "disksizer", "Command that runs disksizer on ssh:// targets")
+	rootCmd.MarkFlagsMutuallyExclusive("agent", "snapshot")
 }
 
 func Execute() {
-    if err := rootCmd.Execute(); err != nil {
-        fmt.Println(err)
-        os.Exit(1)
-    }
+	rootCmd.SetArgs(legacyArgs(os.Args[1:]))
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// legacyArgs rewrites the -profile flag of the versions before the
+// subcommands to --profile, as the flags now need two dashes
+func legacyArgs(args []string) []string {
+	rewritten := make([]string, 0, len(args))
+	for i, arg := range args {
+		if arg == "--" {
+			return append(rewritten, args[i:]...)
+		}
+		if arg == "-profile" || strings.HasPrefix(arg, "-profile=") {
+			arg = "-" + arg
+		}
+		rewritten = append(rewritten, arg)
+	}
+	return rewritten
+}
+
+// loadConfig reads the config file and applies the environment variables and
+// flags overriding it
+func loadConfig(cmd *cobra.Command) error {
+	config, err := Utils.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("error loading config: %v", err)
+	}
+	if err := config.ApplyEnv(); err != nil {
+		return fmt.Errorf("error loading config: %v", err)
+	}
+
+	flags := cmd.Flags()
+	if flags.Changed("tree-sort") {
+		config.Sort = configSort
+	}
+	if flags.Changed("units") {
+		config.Units = configUnits
+	}
+	if flags.Changed("scan-workers") {
+		config.Scan.Workers = configWorkers
+	}
+	if flags.Changed("theme") {
+		config.Theme = configTheme
+	}
+	if flags.Changed("keymap") {
+		config.Keymap = configKeymap
+	}
+	config.Exclude = append(config.Exclude, configExclude...)
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	Utils.ApplyConfig(config)
+	appConfig = config
+	return nil
+}
+
+// startProfiling starts writing a CPU profile to disksizer_cpu.prof
+func startProfiling() error {
+	f, err := os.Create("disksizer_cpu.prof")
+	if err != nil {
+		return fmt.Errorf("could not create CPU profile: %v", err)
+	}
+
+	if err := pprof.StartCPUProfile(f); err != nil {
+		f.Close()
+		return fmt.Errorf("could not start CPU profile: %v", err)
+	}
+	cpuProfile = f
+	return nil
+}
+
+// stopProfiling stops the CPU profile and also captures a memory profile
+func stopProfiling() {
+	if cpuProfile != nil {
+		pprof.StopCPUProfile()
+		cpuProfile.Close()
+		cpuProfile = nil
+	}
+
+	memFile, err := os.Create("disksizer_mem.prof")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not create memory profile: %v\n", err)
+		return
+	}
+	defer memFile.Close()
+
+	runtime.GC() // Get up-to-date statistics
+	if err := pprof.WriteHeapProfile(memFile); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not write memory profile: %v\n", err)
+	}
+}
+
+// scanPath scans the whole tree below path for the report commands
+func scanPath(path string) (Utils.DirEntry, int64, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return Utils.DirEntry{}, 0, err
+	}
+
+	var processedSize int64
+	fmt.Fprintf(os.Stderr, "Scanning %s...\n", absPath)
+	return Utils.ScanDir(absPath, 0, 0, &processedSize)
 }
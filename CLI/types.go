@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"DiskSizer/Utils"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	typesBy   string
+	typesSort string
+	typesTop  int
+)
+
+var typesCmd = &cobra.Command{
+	Use:   "types <path>",
+	Short: "Shows disk usage by file category or extension",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if typesBy != "category" && typesBy != "extension" {
+			return fmt.Errorf("invalid --by value %q, must be category or extension", typesBy)
+		}
+
+		root, _, err := scanPath(args[0])
+		if err != nil {
+			return fmt.Errorf("error scanning path: %v", err)
+		}
+
+		byExtension, byCategory := Utils.TypeBreakdown(root)
+		stats, title := byCategory, "CATEGORY"
+		if typesBy == "extension" {
+			stats, title = byExtension, "EXTENSION"
+		}
+		Utils.SortUsageStats(stats, typesSort)
+		if typesTop > 0 && len(stats) > typesTop {
+			stats = stats[:typesTop]
+		}
+
+		fmt.Printf("File types in %s (%s)\n\n", root.Path, Utils.FormatSize(root.Size))
+		return printUsageStats(title, stats, root.Size)
+	},
+}
+
+func init() {
+	typesCmd.Flags().StringVar(&typesBy, "by", "category", "Group by category or extension")
+	typesCmd.Flags().StringVar(&typesSort, "sort", Utils.SortBySize, "Sort by size, count or name")
+	typesCmd.Flags().IntVar(&typesTop, "top", 0, "Only show the first N rows (0 shows all)")
+	rootCmd.AddCommand(typesCmd)
+}
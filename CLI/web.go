@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"DiskSizer/web"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var webListen string
+
+var webCmd = &cobra.Command{
+	Use:   "web [path]",
+	Short: "Serves a browser UI and JSON API for the scan of a directory",
+	Long: `Scans a directory and serves a single page UI with a sortable table, a
+treemap and a sunburst chart, along with the JSON API it uses:
+
+  GET  /api/status                  state of the scan
+  GET  /api/tree?path=P&depth=N     entry at P with N levels of children
+  GET  /api/search?q=Q&path=P       entries below P whose name contains Q
+  POST /api/rescan                  scan the directory again`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := "."
+		if len(args) > 0 {
+			path = args[0]
+		}
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		if info, err := os.Stat(absPath); err != nil {
+			return err
+		} else if !info.IsDir() {
+			return fmt.Errorf("%s is not a directory", absPath)
+		}
+
+		server := web.NewServer(absPath)
+		server.ScanInBackground()
+
+		fmt.Fprintf(os.Stderr, "Serving %s on http://%s\n", absPath, webListen)
+		return http.ListenAndServe(webListen, server.Handler())
+	},
+}
+
+func init() {
+	webCmd.Flags().StringVar(&webListen, "listen", "127.0.0.1:8080", "Address to serve the UI on")
+	rootCmd.AddCommand(webCmd)
+}
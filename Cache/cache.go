@@ -2,15 +2,27 @@ package cache
 
 import (
 	"DiskSizer/Utils"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 type DirEntry struct {
-	Path     string
-	Name     string
-	Size     int64
-	Children []DirEntry
+	Path       string
+	Name       string
+	Size       int64
+	Allocated  int64
+	IsDir      bool
+	UID        int
+	GID        int
+	ModTime    time.Time
+	AccessTime time.Time
+	Incomplete bool
+	Children   []DirEntry
 }
 
 // DirSizeCache provides caching for directory sizes
@@ -48,6 +60,24 @@ func (c *DirSizeCache) Clear() {
 	c.cache = make(map[string]DirEntry)
 }
 
+// Invalidate drops every cached entry that contains path or lies below it,
+// e.g. after files have been deleted
+func (c *DirSizeCache) Invalidate(path string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for key := range c.cache {
+		if isWithin(path, key) || isWithin(key, path) {
+			delete(c.cache, key)
+		}
+	}
+}
+
+// isWithin reports whether path is dir or lies below it
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
 // FromUtilsDirEntry converts a Utils.DirEntry to a Cache.DirEntry
 func FromUtilsDirEntry(entry Utils.DirEntry) DirEntry {
 	cacheChildren := make([]DirEntry, len(entry.Children))
@@ -56,10 +86,17 @@ func FromUtilsDirEntry(entry Utils.DirEntry) DirEntry {
 	}
 
 	return DirEntry{
-		Path:     entry.Path,
-		Name:     entry.Name,
-		Size:     entry.Size,
-		Children: cacheChildren,
+		Path:       entry.Path,
+		Name:       entry.Name,
+		Size:       entry.Size,
+		Allocated:  entry.Allocated,
+		IsDir:      entry.IsDir,
+		UID:        entry.UID,
+		GID:        entry.GID,
+		ModTime:    entry.ModTime,
+		AccessTime: entry.AccessTime,
+		Incomplete: entry.Incomplete,
+		Children:   cacheChildren,
 	}
 }
 
@@ -71,15 +108,30 @@ func ToUtilsDirEntry(cacheEntry DirEntry) Utils.DirEntry {
 	}
 
 	return Utils.DirEntry{
-		Path:     cacheEntry.Path,
-		Name:     cacheEntry.Name,
-		Size:     cacheEntry.Size,
-		Children: children,
+		Path:       cacheEntry.Path,
+		Name:       cacheEntry.Name,
+		Size:       cacheEntry.Size,
+		Allocated:  cacheEntry.Allocated,
+		IsDir:      cacheEntry.IsDir,
+		UID:        cacheEntry.UID,
+		GID:        cacheEntry.GID,
+		ModTime:    cacheEntry.ModTime,
+		AccessTime: cacheEntry.AccessTime,
+		Incomplete: cacheEntry.Incomplete,
+		Children:   children,
 	}
 }
 
 // CachedScanDir implements a caching layer on top of ScanDir
 func CachedScanDir(path string, maxDepth, currentDepth int, processedSize *int64, cache *DirSizeCache) (Utils.DirEntry, int64, error) {
+	return CachedScan(path, processedSize, cache, func(path string, processedSize *int64) (Utils.DirEntry, int64, error) {
+		return Utils.ScanDir(path, maxDepth, currentDepth, processedSize)
+	})
+}
+
+// CachedScan returns the cached entry for path, or scans it with scan and
+// caches the result
+func CachedScan(path string, processedSize *int64, cache *DirSizeCache, scan func(path string, processedSize *int64) (Utils.DirEntry, int64, error)) (Utils.DirEntry, int64, error) {
 	// Check cache first
 	if cacheEntry, found := cache.Get(path); found {
 		// Convert from Cache.DirEntry to Utils.DirEntry
@@ -89,7 +141,7 @@ func CachedScanDir(path string, maxDepth, currentDepth int, processedSize *int64
 	}
 
 	// Not in cache, scan normally
-	utilsEntry, skipped, err := Utils.ScanDir(path, maxDepth, currentDepth, processedSize)
+	utilsEntry, skipped, err := scan(path, processedSize)
 	if err == nil {
 		// Convert to Cache.DirEntry before adding to cache
 		cacheEntry := FromUtilsDirEntry(utilsEntry)
@@ -98,3 +150,97 @@ func CachedScanDir(path string, maxDepth, currentDepth int, processedSize *int64
 
 	return utilsEntry, skipped, err
 }
+
+// Lookup finds the entry for path, either directly or by descending from the
+// nearest cached ancestor
+func (c *DirSizeCache) Lookup(path string) (DirEntry, bool) {
+	if entry, found := c.Get(path); found {
+		return entry, true
+	}
+
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if entry, found := c.Get(dir); found {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return DirEntry{}, false
+			}
+			return descend(entry, strings.Split(rel, string(filepath.Separator)))
+		}
+		if filepath.Dir(dir) == dir {
+			return DirEntry{}, false
+		}
+	}
+}
+
+// descend follows the given name components down from entry
+func descend(entry DirEntry, names []string) (DirEntry, bool) {
+	for _, name := range names {
+		found := false
+		for _, child := range entry.Children {
+			if child.Name == name {
+				entry = child
+				found = true
+				break
+			}
+		}
+		if !found {
+			return DirEntry{}, false
+		}
+	}
+	return entry, true
+}
+
+// CachedScanChildren scans the directory at path, the root of fsys, one child
+// at a time, reusing any child subtree that is already cached (e.g. a
+// previous tree root) instead of scanning it again. The combined result is
+// cached for path.
+func CachedScanChildren(fsys Utils.FileSystem, path string, processedSize *int64, cache *DirSizeCache) (Utils.DirEntry, int64, error) {
+	entry := Utils.DirEntry{
+		Path:  path,
+		Name:  filepath.Base(path),
+		IsDir: true,
+		UID:   Utils.UnknownOwner,
+		GID:   Utils.UnknownOwner,
+	}
+
+	entries, err := fsys.ReadDir(".")
+	if err != nil {
+		return entry, 0, err
+	}
+
+	var skipped int64
+	for _, e := range entries {
+		childPath := filepath.Join(path, e.Name())
+		if e.Type()&os.ModeSymlink != 0 || Utils.Excluded(childPath) {
+			entry.Incomplete = true
+			continue
+		}
+
+		// An opened archive is cached as a directory, skip it for its file
+		if cacheEntry, found := cache.Lookup(childPath); found && cacheEntry.IsDir == e.IsDir() {
+			child := ToUtilsDirEntry(cacheEntry)
+			atomic.AddInt64(processedSize, child.Size)
+			entry.Children = append(entry.Children, child)
+			entry.Size += child.Size
+			entry.Allocated += child.Allocated
+			continue
+		}
+
+		child, childSkipped, err := Utils.ScanFileSystemDir(fsys, path, e.Name(), 1, 1, processedSize)
+		if err != nil {
+			entry.Incomplete = true
+			continue
+		}
+		entry.Children = append(entry.Children, child)
+		entry.Size += child.Size
+		entry.Allocated += child.Allocated
+		skipped += childSkipped
+	}
+
+	sort.Slice(entry.Children, func(i, j int) bool {
+		return entry.Children[i].Size > entry.Children[j].Size
+	})
+
+	cache.Set(path, FromUtilsDirEntry(entry))
+	return entry, skipped, nil
+}
@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"DiskSizer/Utils"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCachedScanChildren(t *testing.T) {
+	fsys := Utils.NewMemFileSystem()
+	for name, size := range map[string]int64{"a/x": 100, "b/y": 200, "c.txt": 50} {
+		if err := fsys.AddFile(name, size, size, time.Time{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := fsys.AddSymlink("link", time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A cached subtree is reused instead of being scanned again
+	root := filepath.FromSlash("/data")
+	c := NewDirSizeCache()
+	c.Set(filepath.Join(root, "b"), DirEntry{Path: filepath.Join(root, "b"), Name: "b", IsDir: true, Size: 999})
+
+	var processed int64
+	entry, _, err := CachedScanChildren(fsys, root, &processed, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sizes := make(map[string]int64)
+	for _, child := range entry.Children {
+		sizes[child.Name] = child.Size
+	}
+	want := map[string]int64{"b": 999, "a": 100, "c.txt": 50}
+	if len(sizes) != len(want) {
+		t.Errorf("children = %v, want %v", sizes, want)
+	}
+	for name, size := range want {
+		if sizes[name] != size {
+			t.Errorf("%s has size %d, want %d", name, sizes[name], size)
+		}
+	}
+	if entry.Size != 1149 || entry.Children[0].Name != "b" {
+		t.Errorf("size %d with %s first, want 1149 with b first", entry.Size, entry.Children[0].Name)
+	}
+	if cached, found := c.Get(root); !found || cached.Size != 1149 {
+		t.Errorf("result not cached for %s", root)
+	}
+}
@@ -2,41 +2,68 @@ package Utils
 
 import (
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
-	"runtime"
 	"strconv"
 	"strings"
-
-	"golang.org/x/sys/windows"
 )
 
+// FormatSize writes size in the units set by ApplyConfig
 func FormatSize(size int64) string {
-	const (
-		KB = 1 << 10
-		MB = 1 << 20
-		GB = 1 << 30
-		TB = 1 << 40
-	)
-
-	switch {
-	case size >= TB:
-		return FormatFloat(float64(size)/TB) + " TB"
-	case size >= GB:
-		return FormatFloat(float64(size)/GB) + " GB"
-	case size >= MB:
-		return FormatFloat(float64(size)/MB) + " MB"
-	case size >= KB:
-		return FormatFloat(float64(size)/KB) + " KB"
-	default:
-		return FormatFloat(float64(size)) + " B"
+	base, units := float64(1<<10), []string{"B", "KB", "MB", "GB", "TB"}
+	switch sizeUnits {
+	case UnitsIEC:
+		units = []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	case UnitsDecimal:
+		base, units = 1000, []string{"B", "kB", "MB", "GB", "TB"}
+	}
+
+	value, unit := float64(size), 0
+	for value >= base && unit < len(units)-1 {
+		value /= base
+		unit++
 	}
+	return FormatFloat(value) + " " + units[unit]
 }
 
 func FormatFloat(f float64) string {
 	return strconv.FormatFloat(f, 'f', 2, 64)
 }
 
+// ParseSize parses a size such as "512", "1.5 GB", "2GiB" or "20M" in the
+// binary units used by FormatSize
+func ParseSize(s string) (int64, error) {
+	units := []struct {
+		suffix string
+		size   float64
+	}{
+		{"TIB", 1 << 40}, {"GIB", 1 << 30}, {"MIB", 1 << 20}, {"KIB", 1 << 10},
+		{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+		{"T", 1 << 40}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10},
+		{"B", 1},
+	}
+
+	text := strings.ToUpper(strings.TrimSpace(s))
+	multiplier := 1.0
+	for _, unit := range units {
+		if strings.HasSuffix(text, unit.suffix) {
+			text = strings.TrimSpace(strings.TrimSuffix(text, unit.suffix))
+			multiplier = unit.size
+			break
+		}
+	}
+
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil || value < 0 || math.IsInf(value, 0) || math.IsNaN(value) {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	if value*multiplier >= math.MaxInt64 {
+		return 0, fmt.Errorf("size %q is too large", s)
+	}
+	return int64(value * multiplier), nil
+}
+
 func GetSpinnerChars() []string {
 	return []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
 }
@@ -54,41 +81,6 @@ func FastFileInfo(path string) (int64, bool, error) {
 	return size, isDir, nil
 }
 
-// GetUsableSpace returns the available disk space
-func GetUsableSpace(path string) (uint64, error) {
-	// Windows-specific implementation
-	if runtime.GOOS == "windows" {
-		// Get the volume path (e.g., C:\)
-		volumePath := filepath.VolumeName(path)
-		if volumePath == "" {
-			// If path doesn't have a volume name, use the current directory
-			cwd, err := os.Getwd()
-			if err != nil {
-				return 0, err
-			}
-			volumePath = filepath.VolumeName(cwd)
-		}
-
-		// Ensure volume path ends with separator
-		if !strings.HasSuffix(volumePath, "\\") {
-			volumePath += "\\"
-		}
-
-		// Use Windows API via golang.org/x/sys/windows
-		var free, total, totalFree uint64
-		windows.GetDiskFreeSpaceEx(
-			windows.StringToUTF16Ptr(volumePath),
-			&free,
-			&total,
-			&totalFree)
-
-		return free, nil
-	}
-
-	// For non-Windows platforms, return an error
-	return 0, fmt.Errorf("GetUsableSpace not implemented for %s", runtime.GOOS)
-}
-
 // EstimateDirectorySize provides a fast size estimate by sampling
 func EstimateDirectorySize(path string, sampleSize int) (int64, error) {
 	entries, err := os.ReadDir(path)
@@ -139,49 +131,69 @@ func EstimateDirectorySize(path string, sampleSize int) (int64, error) {
 	return estimatedSize, nil
 }
 
-func GetSizeColor(size, totalSize int64) string {
-	if totalSize == 0 {
-		return "white"
-	}
-
-	ratio := float64(size) / float64(totalSize)
+// File categories derived from the file extension
+const (
+	CategoryCode       = "code"
+	CategoryText       = "text"
+	CategoryImage      = "image"
+	CategoryAudio      = "audio"
+	CategoryVideo      = "video"
+	CategoryDocument   = "document"
+	CategoryArchive    = "archive"
+	CategoryExecutable = "executable"
+	CategoryOther      = "other"
+)
 
-	switch {
-	case ratio > 0.5:
-		return "red"
-	case ratio > 0.25:
-		return "orange"
-	case ratio > 0.1:
-		return "yellow"
-	default:
-		return "green"
-	}
+// categoryIcons maps each file category to its icon
+var categoryIcons = map[string]string{
+	CategoryCode:       "🔷",
+	CategoryText:       "📝",
+	CategoryImage:      "🖼️",
+	CategoryAudio:      "🎵",
+	CategoryVideo:      "🎞️",
+	CategoryDocument:   "📕",
+	CategoryArchive:    "📦",
+	CategoryExecutable: "⚙️",
+	CategoryOther:      "📄",
 }
 
-func GetFileIcon(filename string, isDir bool) string {
-	if isDir {
-		return "📁" // Folder icon for directories
-	}
-
-	ext := filepath.Ext(filename)
+// GetFileCategory returns the category of a file based on its extension
+func GetFileCategory(filename string) string {
+	ext := strings.ToLower(filepath.Ext(filename))
 	switch ext {
 	case ".go":
-		return "🔷"
+		return CategoryCode
 	case ".txt", ".md":
-		return "📝"
+		return CategoryText
 	case ".jpg", ".png", ".gif":
-		return "🖼️"
+		return CategoryImage
 	case ".mp3", ".wav":
-		return "🎵"
+		return CategoryAudio
 	case ".mp4", ".avi", ".mov":
-		return "🎞️"
+		return CategoryVideo
 	case ".pdf":
-		return "📕"
-	case ".zip", ".tar", ".gz":
-		return "📦"
+		return CategoryDocument
+	case ".zip", ".tar", ".gz", ".tgz":
+		return CategoryArchive
 	case ".exe", ".app":
-		return "⚙️"
+		return CategoryExecutable
 	default:
-		return "📄"
+		return CategoryOther
+	}
+}
+
+// GetCategoryIcon returns the icon used for a file category
+func GetCategoryIcon(category string) string {
+	if icon, ok := categoryIcons[category]; ok {
+		return icon
 	}
+	return categoryIcons[CategoryOther]
+}
+
+func GetFileIcon(filename string, isDir bool) string {
+	if isDir {
+		return "📁" // Folder icon for directories
+	}
+
+	return GetCategoryIcon(GetFileCategory(filename))
 }
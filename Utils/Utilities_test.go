@@ -0,0 +1,38 @@
+package Utils
+
+import "testing"
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		text string
+		want int64
+		ok   bool
+	}{
+		{"512", 512, true},
+		{" 512 B ", 512, true},
+		{"1.5 GB", 3 << 29, true},
+		{"20M", 20 << 20, true},
+		{"20mb", 20 << 20, true},
+		{"1k", 1 << 10, true},
+		{"2KiB", 2 << 10, true},
+		{"3 MiB", 3 << 20, true},
+		{"1.5gib", 3 << 29, true},
+		{"1TiB", 1 << 40, true},
+		{"0", 0, true},
+		{"", 0, false},
+		{"GB", 0, false},
+		{"-1", 0, false},
+		{"ten MB", 0, false},
+		{"1 PB", 0, false},
+		{"inf", 0, false},
+		{"+Inf GB", 0, false},
+		{"NaN", 0, false},
+		{"1e30 TB", 0, false},
+	}
+	for _, test := range tests {
+		got, err := ParseSize(test.text)
+		if (err == nil) != test.ok || got != test.want {
+			t.Errorf("ParseSize(%q) = %d, %v, want %d (valid: %v)", test.text, got, err, test.want, test.ok)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package Utils
+
+import (
+	"time"
+)
+
+// AgeBucket is one range of the age histogram
+type AgeBucket struct {
+	Label  string
+	MaxAge time.Duration // Zero for the last, open-ended bucket
+}
+
+// Day is the length of one day as used for file ages
+const Day = 24 * time.Hour
+
+// AgeBuckets are the ranges of the age histogram, youngest first
+var AgeBuckets = []AgeBucket{
+	{Label: "< 1 day", MaxAge: Day},
+	{Label: "< 1 week", MaxAge: 7 * Day},
+	{Label: "< 1 month", MaxAge: 30 * Day},
+	{Label: "< 1 year", MaxAge: 365 * Day},
+	{Label: "older", MaxAge: 0},
+}
+
+// FileAge returns how long ago a file was modified, or last accessed if
+// useAccessTime is set
+func FileAge(e DirEntry, now time.Time, useAccessTime bool) time.Duration {
+	if useAccessTime {
+		return now.Sub(e.AccessTime)
+	}
+	return now.Sub(e.ModTime)
+}
+
+// AgeBucketOf returns the label of the bucket an age falls into
+func AgeBucketOf(age time.Duration) string {
+	for _, bucket := range AgeBuckets {
+		if bucket.MaxAge == 0 || age < bucket.MaxAge {
+			return bucket.Label
+		}
+	}
+	return AgeBuckets[len(AgeBuckets)-1].Label
+}
+
+// AgeBreakdown sums the size and number of files below entry per age
+// bucket. The result has one stat per bucket, in the order of AgeBuckets.
+func AgeBreakdown(entry DirEntry, now time.Time, useAccessTime bool) []UsageStat {
+	counter := make(usageCounter)
+	WalkEntries(entry, func(e DirEntry, depth int) bool {
+		if !e.IsDir {
+			counter.add(AgeBucketOf(FileAge(e, now, useAccessTime)), e.Size)
+		}
+		return true
+	})
+
+	stats := make([]UsageStat, len(AgeBuckets))
+	for i, bucket := range AgeBuckets {
+		stats[i] = UsageStat{Key: bucket.Label}
+		if stat, ok := counter[bucket.Label]; ok {
+			stats[i] = *stat
+		}
+	}
+	return stats
+}
+
+// IsStale reports whether a file has not been modified (or accessed) in the
+// given number of days
+func IsStale(e DirEntry, now time.Time, days int, useAccessTime bool) bool {
+	return !e.IsDir && FileAge(e, now, useAccessTime) >= time.Duration(days)*Day
+}
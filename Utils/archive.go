@@ -0,0 +1,171 @@
+package Utils
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+// IsArchive reports whether a file name has the extension of an archive
+// OpenArchive can read: .zip, .tar, .tar.gz or .tgz
+func IsArchive(name string) bool {
+	return archiveKind(name) != ""
+}
+
+func archiveKind(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return "zip"
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return "tar.gz"
+	case strings.HasSuffix(lower, ".tar"):
+		return "tar"
+	}
+	return ""
+}
+
+// OpenArchive reads the index of an archive into an in-memory file system.
+// Each file takes up its compressed size in storage. A .tar.gz is compressed
+// as a whole, so there each file gets the compressed bytes read while reading
+// it, which is only approximate for files smaller than gzip's 32KB window.
+func OpenArchive(file string) (*MemFileSystem, error) {
+	switch archiveKind(file) {
+	case "zip":
+		return readZip(file)
+	case "tar", "tar.gz":
+		return readTar(file)
+	}
+	return nil, fmt.Errorf("%s is not a supported archive", file)
+}
+
+// archiveName turns the name of an archive member into an io/fs name, or ""
+// for names that would lie outside of the archive
+func archiveName(name string) string {
+	name = path.Clean(strings.TrimLeft(strings.ReplaceAll(name, "\\", "/"), "/"))
+	if name == "." || !fs.ValidPath(name) {
+		return ""
+	}
+	return name
+}
+
+func readZip(file string) (*MemFileSystem, error) {
+	reader, err := zip.OpenReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	fsys := NewMemFileSystem()
+	for _, f := range reader.File {
+		name := archiveName(f.Name)
+		if name == "" {
+			continue
+		}
+
+		switch {
+		case f.Mode().IsDir():
+			err = fsys.AddDir(name, f.Modified)
+		case f.Mode()&fs.ModeSymlink != 0:
+			err = fsys.AddSymlink(name, f.Modified)
+		default:
+			err = fsys.AddFile(name, int64(f.UncompressedSize64), int64(f.CompressedSize64), f.Modified)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+	}
+	return fsys, nil
+}
+
+// countingReader counts the bytes read from a file. It is a ByteReader so
+// that gzip reads no further than it needs to, which makes the count exact.
+type countingReader struct {
+	reader *bufio.Reader
+	count  int64
+}
+
+func (r *countingReader) Read(b []byte) (int, error) {
+	n, err := r.reader.Read(b)
+	r.count += int64(n)
+	return n, err
+}
+
+func (r *countingReader) ReadByte() (byte, error) {
+	b, err := r.reader.ReadByte()
+	if err == nil {
+		r.count++
+	}
+	return b, err
+}
+
+func readTar(file string) (*MemFileSystem, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// A plain tar is read from the file itself, so that tar can seek past
+	// the contents instead of reading them
+	var reader io.Reader = f
+	var counter *countingReader
+	gzipped := archiveKind(file) == "tar.gz"
+	if gzipped {
+		counter = &countingReader{reader: bufio.NewReader(f)}
+		gz, err := gzip.NewReader(counter)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	fsys := NewMemFileSystem()
+	tr := tar.NewReader(reader)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+
+		// A tar stores the header and the contents in blocks of 512 bytes
+		stored := (header.Size+511)/512*512 + 512
+		if gzipped {
+			// Read through the contents to learn how much of the archive they take
+			start := counter.count
+			if _, err := io.Copy(io.Discard, tr); err != nil {
+				return nil, fmt.Errorf("%s: %v", file, err)
+			}
+			stored = counter.count - start
+		}
+
+		name := archiveName(header.Name)
+		if name == "" {
+			continue
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = fsys.AddDir(name, header.ModTime)
+		case tar.TypeSymlink:
+			err = fsys.AddSymlink(name, header.ModTime)
+		case tar.TypeReg, tar.TypeLink:
+			err = fsys.AddFile(name, header.Size, stored, header.ModTime)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+	}
+	return fsys, nil
+}
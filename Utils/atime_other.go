@@ -0,0 +1,14 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !windows
+
+package Utils
+
+import (
+	"os"
+	"time"
+)
+
+// fileAccessTime falls back to the modification time where the access time
+// is not available
+func fileAccessTime(info os.FileInfo) time.Time {
+	return info.ModTime()
+}
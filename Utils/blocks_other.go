@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+
+package Utils
+
+import (
+	"os"
+)
+
+// fileBlocks returns the bytes allocated on disk for a file. The allocation
+// is not known on this platform, so it is the size of the file.
+func fileBlocks(info os.FileInfo) int64 {
+	return info.Size()
+}
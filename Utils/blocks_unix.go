@@ -0,0 +1,16 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package Utils
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileBlocks returns the bytes allocated on disk for a file
+func fileBlocks(info os.FileInfo) int64 {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return int64(stat.Blocks) * 512
+	}
+	return info.Size()
+}
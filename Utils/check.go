@@ -0,0 +1,250 @@
+package Utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/disk"
+	"gopkg.in/yaml.v3"
+)
+
+// Kinds of check results
+const (
+	CheckUsage  = "usage"
+	CheckSize   = "size"
+	CheckGrowth = "growth"
+)
+
+// ByteSize is a number of bytes that can be written as "10GB" in config files
+type ByteSize int64
+
+// UnmarshalYAML accepts a plain number of bytes or a size with a unit
+func (s *ByteSize) UnmarshalYAML(value *yaml.Node) error {
+	size, err := ParseSize(value.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: %v", value.Line, err)
+	}
+	*s = ByteSize(size)
+	return nil
+}
+
+// CheckRule is one limit checked by `disksizer check`. A rule either limits
+// the used space of the partition mounted at Partition, or the size and the
+// growth since the last recorded snapshot of the directory at Path.
+type CheckRule struct {
+	Name           string   `yaml:"name"`
+	Partition      string   `yaml:"partition"`
+	MaxUsedPercent float64  `yaml:"maxUsedPercent"`
+	Path           string   `yaml:"path"`
+	MaxSize        ByteSize `yaml:"maxSize"`
+	MaxGrowth      ByteSize `yaml:"maxGrowth"`
+}
+
+// CheckConfig is the rules file read by `disksizer check`
+type CheckConfig struct {
+	History string      `yaml:"history"` // Defaults to DefaultHistoryFile
+	Rules   []CheckRule `yaml:"rules"`
+}
+
+// CheckResult is the outcome of one limit of a rule
+type CheckResult struct {
+	Rule    string  `json:"rule"`
+	Kind    string  `json:"kind"`
+	Target  string  `json:"target"`
+	Value   float64 `json:"value"`
+	Limit   float64 `json:"limit"`
+	OK      bool    `json:"ok"`
+	Message string  `json:"message"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// DefaultCheckFile returns where the rules are read from when no file is
+// given: $XDG_CONFIG_HOME/disksizer/check.yaml
+func DefaultCheckFile() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		configDir = "."
+	}
+	return filepath.Join(configDir, "disksizer", "check.yaml")
+}
+
+// LoadCheckConfig reads and validates a rules file
+func LoadCheckConfig(file string) (CheckConfig, error) {
+	var config CheckConfig
+
+	f, err := os.Open(file)
+	if err != nil {
+		return config, err
+	}
+	defer f.Close()
+
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil {
+		return config, fmt.Errorf("%s: %v", file, err)
+	}
+
+	if config.History == "" {
+		config.History = DefaultHistoryFile()
+	}
+	config.History = ExpandHome(config.History)
+
+	for i := range config.Rules {
+		rule := &config.Rules[i]
+		if err := validateRule(rule); err != nil {
+			return config, fmt.Errorf("%s: rule %d: %v", file, i+1, err)
+		}
+	}
+	return config, nil
+}
+
+// validateRule makes sure a rule limits exactly one target and names it
+func validateRule(rule *CheckRule) error {
+	switch {
+	case rule.Partition != "" && rule.Path != "":
+		return fmt.Errorf("set either partition or path, not both")
+	case rule.Partition != "":
+		if rule.MaxUsedPercent <= 0 || rule.MaxUsedPercent > 100 {
+			return fmt.Errorf("partition rules need maxUsedPercent between 0 and 100")
+		}
+		if rule.MaxSize != 0 || rule.MaxGrowth != 0 {
+			return fmt.Errorf("maxSize and maxGrowth only apply to path rules")
+		}
+		if rule.Name == "" {
+			rule.Name = "partition " + rule.Partition
+		}
+	case rule.Path != "":
+		if rule.MaxSize <= 0 && rule.MaxGrowth <= 0 {
+			return fmt.Errorf("path rules need maxSize or maxGrowth")
+		}
+		if rule.MaxUsedPercent != 0 {
+			return fmt.Errorf("maxUsedPercent only applies to partition rules")
+		}
+		path, err := filepath.Abs(ExpandHome(rule.Path))
+		if err != nil {
+			return err
+		}
+		rule.Path = path
+		if rule.Name == "" {
+			rule.Name = "directory " + rule.Path
+		}
+	default:
+		return fmt.Errorf("set a partition or a path")
+	}
+	return nil
+}
+
+// ExpandHome replaces a leading ~ with the home directory
+func ExpandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
+
+// RunChecks evaluates every rule of config. Each directory is scanned once,
+// no matter how many rules refer to it.
+func RunChecks(config CheckConfig) []CheckResult {
+	var results []CheckResult
+	scans := make(map[string]DirEntry)
+	scanErrors := make(map[string]error)
+
+	for _, rule := range config.Rules {
+		if rule.Partition != "" {
+			results = append(results, checkPartition(rule))
+			continue
+		}
+
+		if _, scanned := scans[rule.Path]; !scanned && scanErrors[rule.Path] == nil {
+			var processedSize int64
+			entry, _, err := ScanDir(rule.Path, 0, 0, &processedSize)
+			if err != nil {
+				scanErrors[rule.Path] = err
+			} else {
+				scans[rule.Path] = entry
+			}
+		}
+
+		if rule.MaxSize > 0 {
+			result := CheckResult{Rule: rule.Name, Kind: CheckSize, Target: rule.Path, Limit: float64(rule.MaxSize)}
+			if err := scanErrors[rule.Path]; err != nil {
+				results = append(results, failedCheck(result, err))
+			} else {
+				results = append(results, checkSize(result, scans[rule.Path]))
+			}
+		}
+
+		if rule.MaxGrowth > 0 {
+			result := CheckResult{Rule: rule.Name, Kind: CheckGrowth, Target: rule.Path, Limit: float64(rule.MaxGrowth)}
+			if err := scanErrors[rule.Path]; err != nil {
+				results = append(results, failedCheck(result, err))
+			} else {
+				results = append(results, checkGrowth(result, scans[rule.Path], config.History))
+			}
+		}
+	}
+	return results
+}
+
+// failedCheck marks a result as failed because it could not be evaluated
+func failedCheck(result CheckResult, err error) CheckResult {
+	result.Error = err.Error()
+	result.Message = fmt.Sprintf("could not check %s: %v", result.Target, err)
+	return result
+}
+
+func checkPartition(rule CheckRule) CheckResult {
+	result := CheckResult{Rule: rule.Name, Kind: CheckUsage, Target: rule.Partition, Limit: rule.MaxUsedPercent}
+
+	usage, err := disk.Usage(rule.Partition)
+	if err != nil {
+		return failedCheck(result, err)
+	}
+
+	result.Value = usage.UsedPercent
+	result.OK = usage.UsedPercent <= rule.MaxUsedPercent
+	result.Message = fmt.Sprintf("%s is %.1f%% full (limit %.1f%%), %s free",
+		rule.Partition, usage.UsedPercent, rule.MaxUsedPercent, FormatSize(int64(usage.Free)))
+	return result
+}
+
+func checkSize(result CheckResult, entry DirEntry) CheckResult {
+	result.Value = float64(entry.Size)
+	result.OK = result.Value <= result.Limit
+	result.Message = fmt.Sprintf("%s is %s (limit %s)",
+		entry.Path, FormatSize(entry.Size), FormatSize(int64(result.Limit)))
+	return result
+}
+
+func checkGrowth(result CheckResult, entry DirEntry, historyFile string) CheckResult {
+	records, err := LoadHistory(historyFile, entry.Path)
+	if err != nil {
+		return failedCheck(result, err)
+	}
+
+	var last HistoryRecord
+	found := false
+	for i := len(records) - 1; i >= 0 && !found; i-- {
+		if _, ok := records[i].Sizes["."]; ok {
+			last, found = records[i], true
+		}
+	}
+	if !found {
+		result.OK = true
+		result.Message = fmt.Sprintf("%s has no snapshot to compare with yet", entry.Path)
+		return result
+	}
+
+	growth := entry.Size - last.Sizes["."]
+	result.Value = float64(growth)
+	result.OK = result.Value <= result.Limit
+	result.Message = fmt.Sprintf("%s grew %s since %s (limit %s)",
+		entry.Path, FormatDelta(growth), last.ScannedAt.Format("2006-01-02 15:04"), FormatSize(int64(result.Limit)))
+	return result
+}
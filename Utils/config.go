@@ -0,0 +1,211 @@
+package Utils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Tree sort orders
+const (
+	SortTreeBySize     = "size"
+	SortTreeByName     = "name"
+	SortTreeByModified = "modified"
+)
+
+// Size units of FormatSize
+const (
+	UnitsBinary  = "binary"  // Powers of 1024 written KB, MB, ...
+	UnitsIEC     = "iec"     // Powers of 1024 written KiB, MiB, ...
+	UnitsDecimal = "decimal" // Powers of 1000 written kB, MB, ...
+)
+
+// Config is the config file, $XDG_CONFIG_HOME/disksizer/config.yaml
+type Config struct {
+	Scan    ScanOptions        `yaml:"scan" json:"scan"`
+	Exclude []string           `yaml:"exclude" json:"exclude"` // Glob patterns for names or, with a separator, full paths
+	Sort    string             `yaml:"sort" json:"sort"`       // Order of the tree: size, name or modified
+	Units   string             `yaml:"units" json:"units"`     // binary, iec or decimal
+	Theme   string             `yaml:"theme" json:"theme"`     // dark, light, high-contrast or mono
+	Colors  map[string]string  `yaml:"colors" json:"colors"`   // Colour names or #rrggbb by element, replacing those of the theme
+	Keymap  string             `yaml:"keymap" json:"keymap"`   // Preset of keys: default or vim
+	Keys    map[string]KeyList `yaml:"keys" json:"keys"`       // Keys by action, replacing those of the keymap
+}
+
+// ScanOptions tune every scan
+type ScanOptions struct {
+	Workers    int  `yaml:"workers" json:"workers"`       // Goroutines per directory scanned in parallel, 0 for one per CPU
+	SkipHidden bool `yaml:"skipHidden" json:"skipHidden"` // Do not scan files and directories starting with a dot
+}
+
+// KeyList is one or more keys, written as a single key or a list in config
+// files
+type KeyList []string
+
+// UnmarshalYAML accepts a single key or a list of keys
+func (k *KeyList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*k = KeyList{value.Value}
+		return nil
+	}
+	var keys []string
+	if err := value.Decode(&keys); err != nil {
+		return err
+	}
+	*k = keys
+	return nil
+}
+
+// The options of the running program
+var (
+	scanOptions ScanOptions
+	excludes    []string
+	sizeUnits   = UnitsBinary
+)
+
+// DefaultConfig returns the settings used without a config file
+func DefaultConfig() Config {
+	return Config{
+		Sort:   SortTreeBySize,
+		Units:  UnitsBinary,
+		Theme:  "dark",
+		Keymap: "default",
+	}
+}
+
+// DefaultConfigFile returns where the config is read from when no file is
+// given: $DISKSIZER_CONFIG, or $XDG_CONFIG_HOME/disksizer/config.yaml
+func DefaultConfigFile() string {
+	if file := os.Getenv("DISKSIZER_CONFIG"); file != "" {
+		return file
+	}
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		configDir = "."
+	}
+	return filepath.Join(configDir, "disksizer", "config.yaml")
+}
+
+// LoadConfig reads a config file over the defaults. A missing file gives the
+// defaults.
+func LoadConfig(file string) (Config, error) {
+	config := DefaultConfig()
+
+	f, err := os.Open(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return config, err
+	}
+	defer f.Close()
+
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil && err != io.EOF {
+		return config, fmt.Errorf("%s: %v", file, err)
+	}
+	if err := config.Validate(); err != nil {
+		return config, fmt.Errorf("%s: %v", file, err)
+	}
+	return config, nil
+}
+
+// ApplyEnv overrides the config with the DISKSIZER_TREE_SORT, DISKSIZER_UNITS,
+// DISKSIZER_SCAN_WORKERS, DISKSIZER_EXCLUDE, DISKSIZER_THEME and
+// DISKSIZER_KEYMAP environment variables. Excludes are separated like PATH
+// and added to those of the config.
+func (c *Config) ApplyEnv() error {
+	if sort := os.Getenv("DISKSIZER_TREE_SORT"); sort != "" {
+		c.Sort = sort
+	}
+	if units := os.Getenv("DISKSIZER_UNITS"); units != "" {
+		c.Units = units
+	}
+	if workers := os.Getenv("DISKSIZER_SCAN_WORKERS"); workers != "" {
+		n, err := strconv.Atoi(workers)
+		if err != nil {
+			return fmt.Errorf("DISKSIZER_SCAN_WORKERS: %q is not a number", workers)
+		}
+		c.Scan.Workers = n
+	}
+	if exclude := os.Getenv("DISKSIZER_EXCLUDE"); exclude != "" {
+		c.Exclude = append(c.Exclude, filepath.SplitList(exclude)...)
+	}
+	if theme := os.Getenv("DISKSIZER_THEME"); theme != "" {
+		c.Theme = theme
+	}
+	if keymap := os.Getenv("DISKSIZER_KEYMAP"); keymap != "" {
+		c.Keymap = keymap
+	}
+	return c.Validate()
+}
+
+// Validate checks the settings that do not depend on the user interface
+func (c Config) Validate() error {
+	switch c.Sort {
+	case SortTreeBySize, SortTreeByName, SortTreeByModified:
+	default:
+		return fmt.Errorf("invalid sort %q, must be size, name or modified", c.Sort)
+	}
+	switch c.Units {
+	case UnitsBinary, UnitsIEC, UnitsDecimal:
+	default:
+		return fmt.Errorf("invalid units %q, must be binary, iec or decimal", c.Units)
+	}
+	if c.Scan.Workers < 0 {
+		return fmt.Errorf("scan workers must not be negative")
+	}
+	for _, pattern := range c.Exclude {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid exclude pattern %q", pattern)
+		}
+	}
+	return nil
+}
+
+// ApplyConfig makes every scan and formatted size follow the config
+func ApplyConfig(config Config) {
+	scanOptions = config.Scan
+	sizeUnits = config.Units
+
+	excludes = make([]string, len(config.Exclude))
+	for i, pattern := range config.Exclude {
+		excludes[i] = filepath.FromSlash(ExpandHome(pattern))
+	}
+}
+
+// Excluded reports whether the file or directory at path is left out of
+// scans
+func Excluded(path string) bool {
+	name := filepath.Base(path)
+	if scanOptions.SkipHidden && strings.HasPrefix(name, ".") {
+		return true
+	}
+	for _, pattern := range excludes {
+		target := name
+		if strings.ContainsRune(pattern, filepath.Separator) {
+			target = path
+		}
+		if ok, _ := filepath.Match(pattern, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// scanWorkers returns how many goroutines scan the children of a directory
+func scanWorkers() int {
+	if scanOptions.Workers > 0 {
+		return scanOptions.Workers
+	}
+	return runtime.NumCPU()
+}
@@ -0,0 +1,93 @@
+package Utils
+
+import (
+	"path/filepath"
+	"sort"
+)
+
+// Kinds of changes between two scans
+const (
+	DiffAdded   = "added"
+	DiffRemoved = "removed"
+	DiffResized = "resized"
+)
+
+// DiffEntry is a path that changed between two scans
+type DiffEntry struct {
+	Path    string
+	Kind    string
+	IsDir   bool
+	OldSize int64
+	NewSize int64
+}
+
+// Delta returns how much the path grew (negative if it shrank)
+func (d DiffEntry) Delta() int64 {
+	return d.NewSize - d.OldSize
+}
+
+// DiffTrees compares two scans of the same directory. Entries are matched by
+// their path relative to the roots, so the scans may come from different
+// locations. Added and removed directories are reported as a whole, resized
+// directories are reported along with the changes inside them. The result is
+// sorted by absolute growth, largest first.
+func DiffTrees(oldRoot, newRoot DirEntry) []DiffEntry {
+	var diffs []DiffEntry
+	diffEntries(oldRoot, newRoot, newRoot.Path, &diffs)
+
+	sort.Slice(diffs, func(i, j int) bool {
+		di, dj := abs64(diffs[i].Delta()), abs64(diffs[j].Delta())
+		if di != dj {
+			return di > dj
+		}
+		return diffs[i].Path < diffs[j].Path
+	})
+	return diffs
+}
+
+func diffEntries(oldEntry, newEntry DirEntry, path string, diffs *[]DiffEntry) {
+	if oldEntry.Size != newEntry.Size {
+		*diffs = append(*diffs, DiffEntry{
+			Path:    path,
+			Kind:    DiffResized,
+			IsDir:   newEntry.IsDir,
+			OldSize: oldEntry.Size,
+			NewSize: newEntry.Size,
+		})
+	}
+
+	oldChildren := make(map[string]DirEntry, len(oldEntry.Children))
+	for _, child := range oldEntry.Children {
+		oldChildren[child.Name] = child
+	}
+
+	for _, child := range newEntry.Children {
+		childPath := filepath.Join(path, child.Name)
+		oldChild, found := oldChildren[child.Name]
+		if !found {
+			*diffs = append(*diffs, DiffEntry{Path: childPath, Kind: DiffAdded, IsDir: child.IsDir, NewSize: child.Size})
+			continue
+		}
+		delete(oldChildren, child.Name)
+		diffEntries(oldChild, child, childPath, diffs)
+	}
+
+	for name, child := range oldChildren {
+		*diffs = append(*diffs, DiffEntry{Path: filepath.Join(path, name), Kind: DiffRemoved, IsDir: child.IsDir, OldSize: child.Size})
+	}
+}
+
+// FormatDelta formats a size change with an explicit sign
+func FormatDelta(delta int64) string {
+	if delta < 0 {
+		return "-" + FormatSize(-delta)
+	}
+	return "+" + FormatSize(delta)
+}
+
+func abs64(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
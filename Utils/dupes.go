@@ -0,0 +1,383 @@
+package Utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// partialHashSize is the number of bytes hashed from the start of each file
+// before the full contents are compared
+const partialHashSize = 4096
+
+// DuplicateGroup is a set of files with identical contents
+type DuplicateGroup struct {
+	Size  int64
+	Hash  string
+	Paths []string
+}
+
+// Wasted returns the bytes that could be reclaimed by keeping one copy
+func (g DuplicateGroup) Wasted() int64 {
+	return g.Size * int64(len(g.Paths)-1)
+}
+
+// FindDuplicates finds files below entry with identical contents. Files are
+// grouped by size first, then by a hash of their first bytes and finally by
+// a hash of their full contents, hashing on up to workers goroutines.
+// Empty files and files smaller than minSize are ignored. Hard links to the
+// same file are not reported as duplicates. Groups are sorted by wasted
+// bytes, largest first.
+func FindDuplicates(entry DirEntry, minSize int64, workers int) []DuplicateGroup {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	minSize = max(minSize, 1)
+
+	bySize := make(map[int64][]string)
+	WalkEntries(entry, func(e DirEntry, depth int) bool {
+		if !e.IsDir && e.Size >= minSize {
+			bySize[e.Size] = append(bySize[e.Size], e.Path)
+		}
+		return true
+	})
+
+	var candidates []DuplicateGroup
+	for size, paths := range bySize {
+		if len(paths) > 1 {
+			candidates = append(candidates, DuplicateGroup{Size: size, Paths: paths})
+		}
+	}
+
+	// Narrow down with a cheap partial hash, then confirm with a full hash
+	candidates = splitByHash(candidates, workers, func(path string) (string, error) {
+		return hashFile(path, partialHashSize)
+	})
+	var small, large []DuplicateGroup
+	for _, group := range candidates {
+		if group.Size <= partialHashSize {
+			small = append(small, group)
+		} else {
+			large = append(large, group)
+		}
+	}
+	large = splitByHash(large, workers, func(path string) (string, error) {
+		return hashFile(path, -1)
+	})
+
+	var groups []DuplicateGroup
+	for _, group := range append(small, large...) {
+		group.Paths = withoutHardLinks(group.Paths)
+		if len(group.Paths) > 1 {
+			sort.Strings(group.Paths)
+			groups = append(groups, group)
+		}
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Wasted() != groups[j].Wasted() {
+			return groups[i].Wasted() > groups[j].Wasted()
+		}
+		return groups[i].Paths[0] < groups[j].Paths[0]
+	})
+	return groups
+}
+
+// splitByHash hashes every file of the groups on a bounded worker pool and
+// splits each group by hash, dropping files that could not be read and
+// groups that are left with a single file
+func splitByHash(groups []DuplicateGroup, workers int, hash func(path string) (string, error)) []DuplicateGroup {
+	type hashResult struct {
+		group int
+		path  string
+		hash  string
+		err   error
+	}
+
+	work := make(chan hashResult)
+	results := make(chan hashResult)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range work {
+				item.hash, item.err = hash(item.path)
+				results <- item
+			}
+		}()
+	}
+
+	go func() {
+		for i, group := range groups {
+			for _, path := range group.Paths {
+				work <- hashResult{group: i, path: path}
+			}
+		}
+		close(work)
+		wg.Wait()
+		close(results)
+	}()
+
+	byHash := make([]map[string][]string, len(groups))
+	for result := range results {
+		if result.err != nil {
+			continue
+		}
+		if byHash[result.group] == nil {
+			byHash[result.group] = make(map[string][]string)
+		}
+		byHash[result.group][result.hash] = append(byHash[result.group][result.hash], result.path)
+	}
+
+	var split []DuplicateGroup
+	for i, hashes := range byHash {
+		for hash, paths := range hashes {
+			if len(paths) > 1 {
+				split = append(split, DuplicateGroup{Size: groups[i].Size, Hash: hash, Paths: paths})
+			}
+		}
+	}
+	return split
+}
+
+// hashFile returns the SHA-256 of the first limit bytes of a file, or of the
+// whole file if limit is negative
+func hashFile(path string, limit int64) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var reader io.Reader = f
+	if limit >= 0 {
+		reader = io.LimitReader(f, limit)
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, reader); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// withoutHardLinks keeps only the first of several paths to the same file
+func withoutHardLinks(paths []string) []string {
+	var unique []string
+	var infos []os.FileInfo
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+
+		linked := false
+		for _, other := range infos {
+			if os.SameFile(info, other) {
+				linked = true
+				break
+			}
+		}
+		if !linked {
+			unique = append(unique, path)
+			infos = append(infos, info)
+		}
+	}
+	return unique
+}
+
+// checkDuplicate makes sure path still has the size of the group before it
+// is removed or replaced
+func checkDuplicate(group DuplicateGroup, path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() || info.Size() != group.Size {
+		return fmt.Errorf("%s has changed since it was scanned", path)
+	}
+	return nil
+}
+
+// checkKeep makes sure the copy to keep still has the contents the group was
+// found with
+func checkKeep(group DuplicateGroup, keep string) error {
+	if err := checkDuplicate(group, keep); err != nil {
+		return err
+	}
+	if group.Hash == "" {
+		return nil
+	}
+	hash, err := hashFile(keep, -1)
+	if err != nil {
+		return err
+	}
+	if hash != group.Hash {
+		return fmt.Errorf("%s has changed since it was compared", keep)
+	}
+	return nil
+}
+
+// stillDuplicate reports whether path still is a copy of keep, comparing
+// their contents byte for byte. Files that are gone or have changed are not.
+func stillDuplicate(group DuplicateGroup, keep, path string) (bool, error) {
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !info.Mode().IsRegular() || info.Size() != group.Size {
+		return false, nil
+	}
+	return sameContents(keep, path)
+}
+
+// sameContents reports whether two files have identical contents
+func sameContents(a, b string) (bool, error) {
+	fa, err := os.Open(a)
+	if err != nil {
+		return false, err
+	}
+	defer fa.Close()
+	fb, err := os.Open(b)
+	if err != nil {
+		return false, err
+	}
+	defer fb.Close()
+
+	bufA := make([]byte, 64*1024)
+	bufB := make([]byte, 64*1024)
+	for {
+		na, errA := io.ReadFull(fa, bufA)
+		nb, errB := io.ReadFull(fb, bufB)
+		if !bytes.Equal(bufA[:na], bufB[:nb]) {
+			return false, nil
+		}
+		doneA := errA == io.EOF || errA == io.ErrUnexpectedEOF
+		doneB := errB == io.EOF || errB == io.ErrUnexpectedEOF
+		switch {
+		case errA != nil && !doneA:
+			return false, errA
+		case errB != nil && !doneB:
+			return false, errB
+		case doneA || doneB:
+			return doneA && doneB, nil
+		}
+	}
+}
+
+// DeleteDuplicates removes every file of the group except keep and returns
+// the paths that were removed. Files whose contents no longer match keep are
+// left alone and returned as skipped.
+func DeleteDuplicates(group DuplicateGroup, keep string) (removed, skipped []string, err error) {
+	if err := checkKeep(group, keep); err != nil {
+		return nil, nil, err
+	}
+
+	for _, path := range group.Paths {
+		if path == keep {
+			continue
+		}
+		same, err := stillDuplicate(group, keep, path)
+		if err != nil {
+			return removed, skipped, err
+		}
+		if !same {
+			skipped = append(skipped, path)
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return removed, skipped, err
+		}
+		removed = append(removed, path)
+	}
+	return removed, skipped, nil
+}
+
+// sameFile reports whether two paths are links to the same file. A path that
+// is gone is not.
+func sameFile(a, b string) (bool, error) {
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false, err
+	}
+	infoB, err := os.Stat(b)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return os.SameFile(infoA, infoB), nil
+}
+
+// tempLinkName returns an unused name next to path for a link that replaces
+// it
+func tempLinkName(path string) (string, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), ".disksizer-link-*")
+	if err != nil {
+		return "", err
+	}
+	f.Close()
+	return f.Name(), os.Remove(f.Name())
+}
+
+// HardLinkDuplicates replaces every file of the group except keep with a
+// hard link to keep and returns the paths that were replaced. Paths that
+// already link to keep are left out, and files whose contents no longer
+// match keep are left alone and returned as skipped.
+func HardLinkDuplicates(group DuplicateGroup, keep string) (linked, skipped []string, err error) {
+	if err := checkKeep(group, keep); err != nil {
+		return nil, nil, err
+	}
+
+	for _, path := range group.Paths {
+		if path == keep {
+			continue
+		}
+		// Renaming a link over another link to the same file does nothing
+		linkedAlready, err := sameFile(keep, path)
+		if err != nil {
+			return linked, skipped, err
+		}
+		if linkedAlready {
+			continue
+		}
+		same, err := stillDuplicate(group, keep, path)
+		if err != nil {
+			return linked, skipped, err
+		}
+		if !same {
+			skipped = append(skipped, path)
+			continue
+		}
+
+		// Link next to the duplicate first so it is replaced atomically
+		tmp, err := tempLinkName(path)
+		if err != nil {
+			return linked, skipped, err
+		}
+		if err := os.Link(keep, tmp); err != nil {
+			return linked, skipped, err
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			os.Remove(tmp)
+			return linked, skipped, err
+		}
+		linked = append(linked, path)
+	}
+	return linked, skipped, nil
+}
@@ -0,0 +1,169 @@
+package Utils
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeFiles creates files with the given contents below dir and returns
+// the scan of dir
+func writeFiles(t *testing.T, dir string, files map[string]string) DirEntry {
+	t.Helper()
+	for name, contents := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var processed int64
+	root, _, err := ScanDir(dir, 0, 0, &processed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+// dupeGroup finds the duplicates below dir, which must form a single group
+func dupeGroup(t *testing.T, root DirEntry) DuplicateGroup {
+	t.Helper()
+	groups := FindDuplicates(root, 1, 2)
+	if len(groups) != 1 {
+		t.Fatalf("found %d groups, want 1: %v", len(groups), groups)
+	}
+	return groups[0]
+}
+
+func TestFindDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	// Larger than the partial hash, differing only after it
+	large := strings.Repeat("x", partialHashSize+10)
+	writeFiles(t, dir, map[string]string{
+		"a.txt":       "same",
+		"sub/b.txt":   "same",
+		"c.txt":       "diff",
+		"big1":        large + "1",
+		"big2":        large + "1",
+		"big3":        large + "2",
+		"empty1":      "",
+		"empty2":      "",
+		"unique.text": "unique contents",
+	})
+	if err := os.Link(filepath.Join(dir, "a.txt"), filepath.Join(dir, "link.txt")); err != nil {
+		t.Skip("hard links are not supported:", err)
+	}
+	root := writeFiles(t, dir, nil)
+
+	groups := FindDuplicates(root, 1, 2)
+	var got [][]string
+	for _, group := range groups {
+		var names []string
+		for _, path := range group.Paths {
+			rel, _ := filepath.Rel(dir, path)
+			names = append(names, filepath.ToSlash(rel))
+		}
+		got = append(got, names)
+	}
+	// The link to a.txt is the same file, so it is left out
+	want := [][]string{{"big1", "big2"}, {"a.txt", "sub/b.txt"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("groups = %v, want %v", got, want)
+	}
+	if groups[0].Wasted() != int64(len(large)+1) {
+		t.Errorf("wasted %d, want %d", groups[0].Wasted(), len(large)+1)
+	}
+}
+
+func TestDeleteDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	group := dupeGroup(t, writeFiles(t, dir, map[string]string{"a": "copy", "b": "copy", "c": "copy", "d": "copy"}))
+	keep := filepath.Join(dir, "a")
+
+	// One copy changes and another disappears after the comparison
+	if err := os.WriteFile(filepath.Join(dir, "c"), []byte("edit"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, "d")); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, skipped, err := DeleteDuplicates(group, keep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(removed, []string{filepath.Join(dir, "b")}) {
+		t.Errorf("removed %v, want b", removed)
+	}
+	if !reflect.DeepEqual(skipped, []string{filepath.Join(dir, "c"), filepath.Join(dir, "d")}) {
+		t.Errorf("skipped %v, want c and d", skipped)
+	}
+	for name, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, err := os.Stat(filepath.Join(dir, name)); (err == nil) != want {
+			t.Errorf("%s exists: %v, want %v", name, err == nil, want)
+		}
+	}
+
+	// A changed copy to keep stops everything
+	if err := os.WriteFile(keep, []byte("gone"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := DeleteDuplicates(group, keep); err == nil {
+		t.Error("DeleteDuplicates kept a copy that has changed")
+	}
+}
+
+func TestHardLinkDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	group := dupeGroup(t, writeFiles(t, dir, map[string]string{"a": "copy", "b": "copy", "c": "copy"}))
+	keep := filepath.Join(dir, "a")
+	if err := os.WriteFile(filepath.Join(dir, "c"), []byte("edit"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// A leftover of an earlier run must not get in the way
+	if err := os.WriteFile(filepath.Join(dir, ".disksizer-link-b"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	linked, skipped, err := HardLinkDuplicates(group, keep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(linked, []string{filepath.Join(dir, "b")}) {
+		t.Errorf("linked %v, want b", linked)
+	}
+	if !reflect.DeepEqual(skipped, []string{filepath.Join(dir, "c")}) {
+		t.Errorf("skipped %v, want c", skipped)
+	}
+	if same, err := sameFile(keep, filepath.Join(dir, "b")); err != nil || !same {
+		t.Errorf("b is not a link to a: %v", err)
+	}
+	if contents, _ := os.ReadFile(filepath.Join(dir, "c")); !bytes.Equal(contents, []byte("edit")) {
+		t.Errorf("c = %q, want it left alone", contents)
+	}
+
+	// Linking again leaves the existing links and nothing else behind
+	linked, _, err = HardLinkDuplicates(group, keep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(linked) != 0 {
+		t.Errorf("linked %v again", linked)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if want := []string{".disksizer-link-b", "a", "b", "c"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("files %v, want %v", names, want)
+	}
+}
@@ -0,0 +1,130 @@
+package Utils
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// EmptyReport lists empty directories and zero-byte files below a path
+type EmptyReport struct {
+	// Dirs holds the topmost directories that contain nothing but other
+	// empty directories
+	Dirs []string
+	// DirCount counts all empty directories, including nested ones
+	DirCount int
+	// Files holds all zero-byte files
+	Files []string
+}
+
+// FindEmpty walks the scan result below entry and collects recursively empty
+// directories and zero-byte files. entry itself is never reported.
+func FindEmpty(entry DirEntry) EmptyReport {
+	var report EmptyReport
+	for _, child := range entry.Children {
+		if empty, count := collectEmpty(child, &report); empty {
+			report.Dirs = append(report.Dirs, child.Path)
+			report.DirCount += count
+		}
+	}
+	return report
+}
+
+// collectEmpty adds the empty entries below entry to report. If entry is an
+// empty directory it is left to the caller to report, and the number of
+// empty directories it consists of is returned. Directories that could not
+// be read completely, or whose symlinks or excluded entries were left out of
+// the scan, are never empty.
+func collectEmpty(entry DirEntry, report *EmptyReport) (bool, int) {
+	if !entry.IsDir {
+		if entry.Size == 0 {
+			report.Files = append(report.Files, entry.Path)
+		}
+		return false, 0
+	}
+
+	var emptyChildren []string
+	emptyCount := 0
+	allEmpty := !entry.Incomplete
+	for _, child := range entry.Children {
+		if empty, count := collectEmpty(child, report); empty {
+			emptyChildren = append(emptyChildren, child.Path)
+			emptyCount += count
+		} else {
+			allEmpty = false
+		}
+	}
+
+	if allEmpty {
+		return true, emptyCount + 1
+	}
+
+	// Only the topmost empty directories are listed
+	report.Dirs = append(report.Dirs, emptyChildren...)
+	report.DirCount += emptyCount
+	return false, 0
+}
+
+// RemoveEmpty deletes the directories and files of a report. Directories are
+// only removed while they are still empty and files only while they are
+// still regular zero-byte files, so anything created since the scan is kept.
+// It goes on after an error and returns all of them.
+func RemoveEmpty(report EmptyReport) (removedDirs, removedFiles int, err error) {
+	var errs []error
+	for _, path := range report.Files {
+		info, statErr := os.Lstat(path)
+		if statErr != nil || !info.Mode().IsRegular() || info.Size() != 0 {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		removedFiles++
+	}
+
+	for _, path := range report.Dirs {
+		count, _, err := removeEmptyDir(path)
+		removedDirs += count
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return removedDirs, removedFiles, errors.Join(errs...)
+}
+
+// removeEmptyDir removes the empty directories at and below path bottom-up.
+// Directories that are no longer empty are kept, which is reported by
+// empty being false.
+func removeEmptyDir(path string) (removed int, empty bool, err error) {
+	entries, err := os.ReadDir(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	empty = true
+	for _, e := range entries {
+		if !e.IsDir() {
+			empty = false
+			continue
+		}
+		count, childEmpty, err := removeEmptyDir(filepath.Join(path, e.Name()))
+		removed += count
+		if err != nil {
+			return removed, false, err
+		}
+		empty = empty && childEmpty
+	}
+	if !empty {
+		return removed, false, nil
+	}
+
+	if err := os.Remove(path); err != nil {
+		return removed, false, err
+	}
+	return removed + 1, true, nil
+}
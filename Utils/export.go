@@ -0,0 +1,72 @@
+package Utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// SnapshotVersion is the version of the JSON export format
+const SnapshotVersion = 1
+
+// Snapshot is a saved scan result, as written by `disksizer scan --format json`
+type Snapshot struct {
+	Version   int       `json:"version"`
+	Path      string    `json:"path"`
+	ScannedAt time.Time `json:"scannedAt"`
+	Skipped   int64     `json:"skipped"`
+	Root      DirEntry  `json:"root"`
+}
+
+// NewSnapshot wraps a scan result taken now
+func NewSnapshot(root DirEntry, skipped int64) Snapshot {
+	return Snapshot{
+		Version:   SnapshotVersion,
+		Path:      root.Path,
+		ScannedAt: time.Now(),
+		Skipped:   skipped,
+		Root:      root,
+	}
+}
+
+// WriteSnapshot writes a snapshot as JSON
+func WriteSnapshot(w io.Writer, snapshot Snapshot) error {
+	return json.NewEncoder(w).Encode(snapshot)
+}
+
+// ReadSnapshot reads a snapshot written by WriteSnapshot
+func ReadSnapshot(r io.Reader) (Snapshot, error) {
+	var snapshot Snapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return snapshot, fmt.Errorf("invalid snapshot: %v", err)
+	}
+	if snapshot.Version != SnapshotVersion {
+		return snapshot, fmt.Errorf("unsupported snapshot version %d", snapshot.Version)
+	}
+	return snapshot, nil
+}
+
+// SaveSnapshot writes a snapshot to a file
+func SaveSnapshot(path string, snapshot Snapshot) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WriteSnapshot(f, snapshot); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadSnapshot reads a snapshot from a file
+func LoadSnapshot(path string) (Snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	defer f.Close()
+	return ReadSnapshot(f)
+}
@@ -0,0 +1,84 @@
+package Utils
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// FileSystem is a source the scanner can read. It is an io/fs file system,
+// so names are slash-separated and relative to its root, with Lstat added
+// because the scanner must not follow symlinks.
+type FileSystem interface {
+	fs.ReadDirFS
+
+	// Lstat returns the info of name without following a final symlink
+	Lstat(name string) (fs.FileInfo, error)
+}
+
+// BlocksFileSystem is a FileSystem that also knows how much space each file
+// takes up in storage, e.g. the blocks allocated on disk or the compressed
+// size in an archive
+type BlocksFileSystem interface {
+	FileSystem
+
+	// LstatBlocks is Lstat that also returns the bytes stored for name
+	LstatBlocks(name string) (fs.FileInfo, int64, error)
+}
+
+// OSFileSystem is the directory tree of the local disks below a directory
+type OSFileSystem string
+
+// NewOSFileSystem returns the local file system rooted at dir
+func NewOSFileSystem(dir string) OSFileSystem {
+	return OSFileSystem(dir)
+}
+
+// path returns the OS path of an io/fs name
+func (f OSFileSystem) path(op, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	return filepath.Join(string(f), filepath.FromSlash(name)), nil
+}
+
+func (f OSFileSystem) Open(name string) (fs.File, error) {
+	p, err := f.path("open", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(p)
+}
+
+func (f OSFileSystem) ReadDir(name string) ([]fs.DirEntry, error) {
+	p, err := f.path("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadDir(p)
+}
+
+func (f OSFileSystem) Lstat(name string) (fs.FileInfo, error) {
+	p, err := f.path("lstat", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Lstat(p)
+}
+
+func (f OSFileSystem) LstatBlocks(name string) (fs.FileInfo, int64, error) {
+	info, err := f.Lstat(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	return info, fileBlocks(info), nil
+}
+
+// joinName joins an io/fs name and a child name
+func joinName(dir, name string) string {
+	if dir == "." {
+		return name
+	}
+	return path.Join(dir, name)
+}
@@ -0,0 +1,632 @@
+package Utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Status of a path in a git repository
+const (
+	GitTracked   = "tracked"
+	GitUntracked = "untracked"
+	GitIgnored   = "ignored"
+	GitData      = "git" // .git itself
+)
+
+// GitRepo is a git repository read from disk, without running git
+type GitRepo struct {
+	Root        string
+	GitDir      string
+	tracked     map[string]string // Object IDs of the files in the index, by slash-separated path
+	trackedDirs map[string]bool   // Directories holding tracked files
+
+	mu      sync.Mutex
+	ignores map[string][]ignoreRule // Rules of the .gitignore files read so far, by directory
+	exclude []ignoreRule            // Rules of .git/info/exclude
+}
+
+// ignoreRule is a pattern of a .gitignore file
+type ignoreRule struct {
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool // Matched against the path relative to the .gitignore, not the name
+}
+
+// GitUsage counts files and bytes
+type GitUsage struct {
+	Files int   `json:"files"`
+	Size  int64 `json:"size"`
+}
+
+// GitObject is an object stored in a packfile
+type GitObject struct {
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	Size       int64  `json:"size"`           // Size of the object, or of the delta for deltified objects
+	PackedSize int64  `json:"packedSize"`     // Bytes taken up in the packfile
+	Path       string `json:"path,omitempty"` // Path in the index, if the object is a tracked file
+}
+
+// GitReport breaks down the space of a repository
+type GitReport struct {
+	Root         string      `json:"root"`
+	GitDir       string      `json:"gitDir"`
+	GitSize      int64       `json:"gitSize"`   // All of .git
+	PackSize     int64       `json:"packSize"`  // Packfiles and their indexes
+	LooseSize    int64       `json:"looseSize"` // Other objects in .git/objects
+	Tracked      GitUsage    `json:"tracked"`
+	Untracked    GitUsage    `json:"untracked"`
+	Ignored      GitUsage    `json:"ignored"`
+	TopUntracked []UsageStat `json:"topUntracked"` // Topmost untracked paths, largest first
+	TopIgnored   []UsageStat `json:"topIgnored"`   // Topmost ignored paths, largest first
+	LargeObjects []GitObject `json:"largeObjects"` // Largest first
+}
+
+// FindGitRepo returns the root of the repository containing path
+func FindGitRepo(path string) (string, bool) {
+	for dir := path; ; dir = filepath.Dir(dir) {
+		if _, err := os.Lstat(filepath.Join(dir, ".git")); err == nil {
+			return dir, true
+		}
+		if filepath.Dir(dir) == dir {
+			return "", false
+		}
+	}
+}
+
+// OpenGitRepo reads the index of the repository at root. .git may also be
+// a file pointing to the git directory, as in worktrees and submodules.
+func OpenGitRepo(root string) (*GitRepo, error) {
+	gitDir := filepath.Join(root, ".git")
+	info, err := os.Stat(gitDir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		data, err := os.ReadFile(gitDir)
+		if err != nil {
+			return nil, err
+		}
+		target, found := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+		if !found {
+			return nil, fmt.Errorf("%s is not a git directory", gitDir)
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(root, target)
+		}
+		gitDir = filepath.Clean(target)
+	}
+
+	repo := &GitRepo{
+		Root:        root,
+		GitDir:      gitDir,
+		tracked:     make(map[string]string),
+		trackedDirs: make(map[string]bool),
+		ignores:     make(map[string][]ignoreRule),
+	}
+	if err := repo.readIndex(); err != nil {
+		return nil, err
+	}
+	if data, err := os.ReadFile(filepath.Join(commonGitDir(gitDir), "info", "exclude")); err == nil {
+		repo.exclude = parseIgnoreRules(data)
+	}
+	return repo, nil
+}
+
+// commonGitDir returns the git directory shared by the worktrees of gitDir
+func commonGitDir(gitDir string) string {
+	data, err := os.ReadFile(filepath.Join(gitDir, "commondir"))
+	if err != nil {
+		return gitDir
+	}
+	common := strings.TrimSpace(string(data))
+	if !filepath.IsAbs(common) {
+		common = filepath.Join(gitDir, common)
+	}
+	return filepath.Clean(common)
+}
+
+// readIndex reads the tracked files from the index, versions 2 to 4. A
+// missing index means nothing is tracked yet.
+func (r *GitRepo) readIndex() error {
+	file := filepath.Join(r.GitDir, "index")
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(data) < 12 || string(data[:4]) != "DIRC" {
+		return fmt.Errorf("%s is not a git index", file)
+	}
+	version := binary.BigEndian.Uint32(data[4:8])
+	if version < 2 || version > 4 {
+		return fmt.Errorf("%s: unsupported index version %d", file, version)
+	}
+	count := binary.BigEndian.Uint32(data[8:12])
+
+	pos := 12
+	previous := ""
+	for i := uint32(0); i < count; i++ {
+		start := pos
+		if pos+62 > len(data) {
+			return fmt.Errorf("%s: truncated entry %d", file, i)
+		}
+		id := hex.EncodeToString(data[pos+40 : pos+60])
+		flags := binary.BigEndian.Uint16(data[pos+60 : pos+62])
+		pos += 62
+		if version >= 3 && flags&0x4000 != 0 {
+			pos += 2
+		}
+
+		var name string
+		if version == 4 {
+			// The name replaces the end of the previous name
+			strip, n := readIndexVarint(data[pos:])
+			if n == 0 || strip > len(previous) {
+				return fmt.Errorf("%s: invalid entry %d", file, i)
+			}
+			pos += n
+			end := bytes.IndexByte(data[pos:], 0)
+			if end < 0 {
+				return fmt.Errorf("%s: truncated entry %d", file, i)
+			}
+			name = previous[:len(previous)-strip] + string(data[pos:pos+end])
+			pos += end + 1
+		} else {
+			end := bytes.IndexByte(data[pos:], 0)
+			if end < 0 {
+				return fmt.Errorf("%s: truncated entry %d", file, i)
+			}
+			name = string(data[pos : pos+end])
+			// Entries are padded with NULs to a multiple of 8 bytes
+			pos = start + (pos+end-start+8)&^7
+		}
+		previous = name
+
+		r.tracked[strings.TrimSuffix(name, "/")] = id
+		for dir := path.Dir(strings.TrimSuffix(name, "/")); dir != "."; dir = path.Dir(dir) {
+			if r.trackedDirs[dir] {
+				break
+			}
+			r.trackedDirs[dir] = true
+		}
+	}
+	return nil
+}
+
+// readIndexVarint reads a variable length number of a version 4 index and
+// returns it with the number of bytes read, 0 if data ends early
+func readIndexVarint(data []byte) (int, int) {
+	value := 0
+	for i, b := range data {
+		if i > 0 {
+			value = (value + 1) << 7
+		}
+		value += int(b & 0x7f)
+		if b&0x80 == 0 {
+			return value, i + 1
+		}
+	}
+	return 0, 0
+}
+
+// parseIgnoreRules parses the patterns of a .gitignore file
+func parseIgnoreRules(data []byte) []ignoreRule {
+	var rules []ignoreRule
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, " \r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var rule ignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		rule.pattern = line
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// matches reports whether the rule matches rel, a path relative to the
+// directory of its .gitignore
+func (rule ignoreRule) matches(rel string, isDir bool) bool {
+	if rule.dirOnly && !isDir {
+		return false
+	}
+	if !rule.anchored {
+		matched, _ := path.Match(rule.pattern, path.Base(rel))
+		return matched
+	}
+	return matchGlobSegments(strings.Split(rule.pattern, "/"), strings.Split(rel, "/"))
+}
+
+// matchGlobSegments matches path segments against pattern segments, where
+// ** matches any number of segments
+func matchGlobSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			if len(pattern) == 1 {
+				return len(name) > 0
+			}
+			for i := 0; i <= len(name); i++ {
+				if matchGlobSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if matched, _ := path.Match(pattern[0], name[0]); !matched {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// ignoreRules returns the rules of the .gitignore in dir, reading it once
+func (r *GitRepo) ignoreRules(dir string) []ignoreRule {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rules, found := r.ignores[dir]
+	if !found {
+		data, err := os.ReadFile(filepath.Join(r.Root, filepath.FromSlash(dir), ".gitignore"))
+		if err == nil {
+			rules = parseIgnoreRules(data)
+		}
+		r.ignores[dir] = rules
+	}
+	return rules
+}
+
+// ignored reports whether the last rule matching rel ignores it. Rules of
+// deeper .gitignore files take precedence, .git/info/exclude comes first.
+func (r *GitRepo) ignored(rel string, isDir bool) bool {
+	ignored := false
+	for _, rule := range r.exclude {
+		if rule.matches(rel, isDir) {
+			ignored = !rule.negate
+		}
+	}
+
+	dir := "."
+	for {
+		relToDir := rel
+		if dir != "." {
+			relToDir = strings.TrimPrefix(rel, dir+"/")
+		}
+		for _, rule := range r.ignoreRules(dir) {
+			if rule.matches(relToDir, isDir) {
+				ignored = !rule.negate
+			}
+		}
+
+		next, _, found := strings.Cut(relToDir, "/")
+		if !found {
+			return ignored
+		}
+		if dir == "." {
+			dir = next
+		} else {
+			dir = dir + "/" + next
+		}
+	}
+}
+
+// Status returns whether path is tracked, untracked, ignored or part of
+// .git. Directories count as tracked if they hold tracked files.
+func (r *GitRepo) Status(path string, isDir bool) string {
+	rel, err := filepath.Rel(r.Root, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	return r.status(filepath.ToSlash(rel), isDir, true)
+}
+
+// status returns the status of a slash-separated path relative to the root.
+// Nothing below an ignored directory can be included again, so unless the
+// caller knows better the parent directories are checked too.
+func (r *GitRepo) status(rel string, isDir, checkParents bool) string {
+	if rel == ".git" || strings.HasPrefix(rel, ".git/") {
+		return GitData
+	}
+
+	if isDir && r.trackedDirs[rel] {
+		return GitTracked
+	}
+	if !isDir {
+		if _, tracked := r.tracked[rel]; tracked {
+			return GitTracked
+		}
+	}
+
+	if checkParents {
+		parts := strings.Split(rel, "/")
+		for i := 1; i < len(parts); i++ {
+			if dir := strings.Join(parts[:i], "/"); !r.trackedDirs[dir] && r.ignored(dir, true) {
+				return GitIgnored
+			}
+		}
+	}
+	if r.ignored(rel, isDir) {
+		return GitIgnored
+	}
+	return GitUntracked
+}
+
+// Report breaks down the space of the repository from the scan of its root.
+// top limits the lists of paths and objects.
+func (r *GitRepo) Report(root DirEntry, top int) (GitReport, error) {
+	report := GitReport{Root: r.Root, GitDir: r.GitDir}
+
+	var untracked, ignored []UsageStat
+	var walk func(entry DirEntry, rel, entryStatus string)
+	walk = func(entry DirEntry, rel, entryStatus string) {
+		for _, child := range entry.Children {
+			childRel := child.Name
+			if rel != "" {
+				childRel = rel + "/" + child.Name
+			}
+
+			// The walk does not enter ignored directories
+			status := r.status(childRel, child.IsDir, false)
+			switch {
+			case status == GitData:
+				continue
+			case status == GitIgnored:
+				files := countFiles(child)
+				report.Ignored.Files += files
+				report.Ignored.Size += child.Size
+				ignored = append(ignored, UsageStat{Key: child.Path, Size: child.Size, Count: files})
+			case child.IsDir:
+				before := report.Untracked
+				walk(child, childRel, status)
+				if status == GitUntracked && entryStatus != GitUntracked && report.Untracked.Files > before.Files {
+					untracked = append(untracked, UsageStat{Key: child.Path,
+						Size: report.Untracked.Size - before.Size, Count: report.Untracked.Files - before.Files})
+				}
+			case status == GitTracked:
+				report.Tracked.Files++
+				report.Tracked.Size += child.Size
+			default:
+				report.Untracked.Files++
+				report.Untracked.Size += child.Size
+				if entryStatus != GitUntracked {
+					untracked = append(untracked, UsageStat{Key: child.Path, Size: child.Size, Count: 1})
+				}
+			}
+		}
+	}
+	walk(root, "", "")
+	report.TopUntracked = topUsageStats(untracked, top)
+	report.TopIgnored = topUsageStats(ignored, top)
+
+	// Size up .git from the scan if it is part of it
+	gitEntry := childEntry(root, ".git")
+	if !gitEntry.IsDir {
+		var processedSize int64
+		var err error
+		if gitEntry, _, err = ScanDir(r.GitDir, 0, 0, &processedSize); err != nil {
+			return report, err
+		}
+	}
+	report.GitSize = gitEntry.Size
+	objects := childEntry(gitEntry, "objects")
+	report.PackSize = childEntry(objects, "pack").Size
+	report.LooseSize = objects.Size - report.PackSize
+
+	largeObjects, err := r.packedObjects(top)
+	report.LargeObjects = largeObjects
+	return report, err
+}
+
+// childEntry returns the child of entry with the given name
+func childEntry(entry DirEntry, name string) DirEntry {
+	for _, child := range entry.Children {
+		if child.Name == name {
+			return child
+		}
+	}
+	return DirEntry{}
+}
+
+// countFiles counts the files below entry, or 1 for a file
+func countFiles(entry DirEntry) int {
+	if !entry.IsDir {
+		return 1
+	}
+	count := 0
+	for _, child := range entry.Children {
+		count += countFiles(child)
+	}
+	return count
+}
+
+// topUsageStats returns the largest n stats
+func topUsageStats(stats []UsageStat, n int) []UsageStat {
+	SortUsageStats(stats, SortBySize)
+	if n > 0 && len(stats) > n {
+		stats = stats[:n]
+	}
+	return stats
+}
+
+// Object types in packfiles
+var packObjectTypes = map[byte]string{
+	1: "commit",
+	2: "tree",
+	3: "blob",
+	4: "tag",
+	6: "ofs-delta",
+	7: "ref-delta",
+}
+
+// packedObjects returns the n largest objects of all packfiles by the space
+// they take up in the pack
+func (r *GitRepo) packedObjects(n int) ([]GitObject, error) {
+	packDir := filepath.Join(commonGitDir(r.GitDir), "objects", "pack")
+	indexes, err := filepath.Glob(filepath.Join(packDir, "*.idx"))
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make(map[string]string, len(r.tracked))
+	for name, id := range r.tracked {
+		paths[id] = name
+	}
+
+	var objects []packEntry
+	for _, index := range indexes {
+		packFile := strings.TrimSuffix(index, ".idx") + ".pack"
+		packObjects, err := readPackIndex(index, packFile)
+		if err != nil {
+			return nil, err
+		}
+
+		sort.Slice(packObjects, func(i, j int) bool {
+			return packObjects[i].PackedSize > packObjects[j].PackedSize
+		})
+		if n > 0 && len(packObjects) > n {
+			packObjects = packObjects[:n]
+		}
+		if err := readPackHeaders(packFile, packObjects); err != nil {
+			return nil, err
+		}
+		objects = append(objects, packObjects...)
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].PackedSize > objects[j].PackedSize
+	})
+	if n > 0 && len(objects) > n {
+		objects = objects[:n]
+	}
+	result := make([]GitObject, len(objects))
+	for i, object := range objects {
+		result[i] = object.GitObject
+		result[i].Path = paths[object.ID]
+	}
+	return result, nil
+}
+
+// packEntry is an object at an offset in a packfile
+type packEntry struct {
+	GitObject
+	offset int64
+}
+
+// readPackIndex reads the object IDs and offsets of a version 2 pack index.
+// An object takes up the space up to the next object in the pack, its type
+// and size are left to readPackHeaders.
+func readPackIndex(index, packFile string) ([]packEntry, error) {
+	data, err := os.ReadFile(index)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 8+256*4 || string(data[:4]) != "\xfftOc" || binary.BigEndian.Uint32(data[4:8]) != 2 {
+		return nil, fmt.Errorf("%s: unsupported pack index", index)
+	}
+	count := int(binary.BigEndian.Uint32(data[8+255*4:]))
+
+	idStart := 8 + 256*4
+	offsetStart := idStart + count*20 + count*4
+	largeStart := offsetStart + count*4
+	if len(data) < largeStart {
+		return nil, fmt.Errorf("%s: truncated pack index", index)
+	}
+
+	packInfo, err := os.Stat(packFile)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]packEntry, count)
+	for i := range objects {
+		objects[i].ID = hex.EncodeToString(data[idStart+i*20 : idStart+i*20+20])
+		offset := int64(binary.BigEndian.Uint32(data[offsetStart+i*4:]))
+		if offset&0x80000000 != 0 {
+			large := largeStart + int(offset&0x7fffffff)*8
+			if len(data) < large+8 {
+				return nil, fmt.Errorf("%s: truncated pack index", index)
+			}
+			offset = int64(binary.BigEndian.Uint64(data[large:]))
+		}
+		objects[i].offset = offset
+	}
+
+	// The pack ends with a 20 byte checksum
+	sort.Slice(objects, func(i, j int) bool { return objects[i].offset < objects[j].offset })
+	for i := range objects {
+		end := packInfo.Size() - 20
+		if i+1 < count {
+			end = objects[i+1].offset
+		}
+		objects[i].PackedSize = end - objects[i].offset
+	}
+	return objects, nil
+}
+
+// readPackHeaders reads the type and size of objects from their headers in
+// the pack
+func readPackHeaders(packFile string, objects []packEntry) error {
+	f, err := os.Open(packFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	header := make([]byte, 16)
+	for i := range objects {
+		n, err := f.ReadAt(header, objects[i].offset)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if n == 0 {
+			return fmt.Errorf("%s: object %s lies outside the pack", packFile, objects[i].ID)
+		}
+
+		// The type is in bits 4-6 of the first byte, the size follows in
+		// 4 bits and then 7 bits per byte
+		objects[i].Type = packObjectTypes[(header[0]>>4)&7]
+		size := int64(header[0] & 0x0f)
+		shift := 4
+		for j := 0; j < n && header[j]&0x80 != 0 && j+1 < n; j++ {
+			size |= int64(header[j+1]&0x7f) << shift
+			shift += 7
+		}
+		objects[i].Size = size
+	}
+	return nil
+}
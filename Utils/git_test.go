@@ -0,0 +1,251 @@
+package Utils
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// runGit runs git in dir and returns its output, failing the test on errors
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, output)
+	}
+	return string(output)
+}
+
+// gitTestRepo creates a repository with tracked, untracked and ignored files
+// and returns its root. The test is skipped without git.
+func gitTestRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	// Keep the configuration of the user out of the tests
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q")
+	writeFiles(t, dir, map[string]string{
+		".gitignore":              "*.log\n!keep.log\nbuild/\n!build/keep.txt\n/root-only.txt\ndocs/**/draft.md\n**/tmp\n",
+		"a.txt":                   "a",
+		"forced.log":              "forced",
+		"keep.log":                "keep",
+		"debug.log":               "debug",
+		"root-only.txt":           "root",
+		"build/out.bin":           "out",
+		"build/keep.txt":          "keep",
+		"build/nested/deep.txt":   "deep",
+		"docs/readme.md":          "readme",
+		"docs/draft.md":           "draft",
+		"docs/a/b/draft.md":       "draft",
+		"sub/.gitignore":          "*.dat\n!important.dat\n",
+		"sub/x.txt":               "x",
+		"sub/a.dat":               "a",
+		"sub/important.dat":       "important",
+		"sub/root-only.txt":       "not anchored here",
+		"sub/tmp/scratch":         "scratch",
+		"sub/new/untracked.txt":   "new",
+		"only-ignored/trace.log":  "trace",
+		"untracked-dir/file.txt":  "file",
+		"untracked-dir/a/b/c.txt": "c",
+	})
+	runGit(t, dir, "add", ".gitignore", "a.txt", "keep.log", "docs/readme.md", "sub/.gitignore", "sub/x.txt", "sub/important.dat")
+	runGit(t, dir, "add", "-f", "forced.log")
+	runGit(t, dir, "commit", "-q", "-m", "initial")
+	return dir
+}
+
+// gitLsFiles returns the paths listed by git ls-files with args
+func gitLsFiles(t *testing.T, dir string, args ...string) map[string]bool {
+	t.Helper()
+	paths := make(map[string]bool)
+	for _, line := range strings.Split(runGit(t, dir, append([]string{"ls-files"}, args...)...), "\n") {
+		if line != "" {
+			paths[line] = true
+		}
+	}
+	return paths
+}
+
+func TestGitRepoStatus(t *testing.T) {
+	dir := gitTestRepo(t)
+	tracked := gitLsFiles(t, dir)
+	ignored := gitLsFiles(t, dir, "--others", "--ignored", "--exclude-standard")
+	untracked := gitLsFiles(t, dir, "--others", "--exclude-standard")
+
+	for _, version := range []uint32{2, 3, 4} {
+		// Intent-to-add needs an extended flag, which version 2 cannot hold
+		if version == 3 {
+			runGit(t, dir, "add", "-N", "sub/new/untracked.txt")
+			tracked["sub/new/untracked.txt"] = true
+			delete(untracked, "sub/new/untracked.txt")
+		}
+		runGit(t, dir, "update-index", "--index-version", strconv.Itoa(int(version)))
+		data, err := os.ReadFile(filepath.Join(dir, ".git", "index"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := binary.BigEndian.Uint32(data[4:8]); got != version {
+			t.Fatalf("git wrote index version %d, want %d", got, version)
+		}
+
+		repo, err := OpenGitRepo(dir)
+		if err != nil {
+			t.Fatalf("version %d: %v", version, err)
+		}
+
+		err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			rel := filepath.ToSlash(strings.TrimPrefix(path, dir+string(filepath.Separator)))
+			if path == dir {
+				return nil
+			}
+			if rel == ".git" {
+				if status := repo.Status(path, true); status != GitData {
+					t.Errorf("version %d: .git is %q, want %q", version, status, GitData)
+				}
+				return filepath.SkipDir
+			}
+
+			var want string
+			switch {
+			case info.IsDir():
+				want = gitDirStatus(t, dir, rel, tracked)
+			case tracked[rel]:
+				want = GitTracked
+			case ignored[rel]:
+				want = GitIgnored
+			case untracked[rel]:
+				want = GitUntracked
+			default:
+				t.Fatalf("git does not list %s", rel)
+			}
+			if status := repo.Status(path, info.IsDir()); status != want {
+				t.Errorf("version %d: %s is %q, want %q", version, rel, status, want)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// gitDirStatus returns the status git gives the directory rel
+func gitDirStatus(t *testing.T, dir, rel string, tracked map[string]bool) string {
+	t.Helper()
+	for path := range tracked {
+		if strings.HasPrefix(path, rel+"/") {
+			return GitTracked
+		}
+	}
+	cmd := exec.Command("git", "check-ignore", "-q", rel+"/")
+	cmd.Dir = dir
+	err := cmd.Run()
+	if err == nil {
+		return GitIgnored
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		return GitUntracked
+	}
+	t.Fatalf("git check-ignore %s: %v", rel, err)
+	return ""
+}
+
+func TestReadPack(t *testing.T) {
+	dir := gitTestRepo(t)
+	// Similar versions of a file give deltified objects, random contents
+	// keep them the largest ones
+	contents := make([]byte, 20000)
+	rand.New(rand.NewSource(1)).Read(contents)
+	for i := 0; i < 3; i++ {
+		contents = append(contents, byte(i))
+		writeFiles(t, dir, map[string]string{"big.txt": string(contents)})
+		runGit(t, dir, "add", "big.txt")
+		runGit(t, dir, "commit", "-q", "-m", "version "+strconv.Itoa(i))
+	}
+	runGit(t, dir, "repack", "-adq")
+
+	indexes, err := filepath.Glob(filepath.Join(dir, ".git", "objects", "pack", "*.idx"))
+	if err != nil || len(indexes) != 1 {
+		t.Fatalf("found packs %v, %v, want one", indexes, err)
+	}
+	index := indexes[0]
+	packFile := strings.TrimSuffix(index, ".idx") + ".pack"
+
+	objects, err := readPackIndex(index, packFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := readPackHeaders(packFile, objects); err != nil {
+		t.Fatal(err)
+	}
+	byID := make(map[string]packEntry, len(objects))
+	for _, object := range objects {
+		byID[object.ID] = object
+	}
+
+	// Lines are: id type size packed-size offset [depth base]
+	var listed []string
+	deltas := 0
+	for _, line := range strings.Split(runGit(t, dir, "verify-pack", "-v", index), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 || len(fields[0]) != 40 {
+			continue
+		}
+		listed = append(listed, fields[0])
+		object, found := byID[fields[0]]
+		if !found {
+			t.Errorf("object %s is missing", fields[0])
+			continue
+		}
+		packedSize, _ := strconv.ParseInt(fields[3], 10, 64)
+		offset, _ := strconv.ParseInt(fields[4], 10, 64)
+		if object.PackedSize != packedSize || object.offset != offset {
+			t.Errorf("object %s takes %d bytes at %d, want %d at %d", object.ID, object.PackedSize, object.offset, packedSize, offset)
+		}
+		// Deltas are listed with the type and size of the object they
+		// resolve to, the pack stores those of the delta
+		if len(fields) == 5 {
+			size, _ := strconv.ParseInt(fields[2], 10, 64)
+			if object.Type != fields[1] || object.Size != size {
+				t.Errorf("object %s is a %s of %d bytes, want a %s of %s", object.ID, object.Type, object.Size, fields[1], fields[2])
+			}
+		} else if !strings.HasSuffix(object.Type, "-delta") {
+			t.Errorf("object %s is a %s, want a delta", object.ID, object.Type)
+		} else {
+			deltas++
+		}
+	}
+	if len(listed) != len(objects) || deltas == 0 {
+		t.Errorf("read %d objects, git lists %d with %d deltas", len(objects), len(listed), deltas)
+	}
+
+	// The report names the tracked files of the largest objects
+	repo, err := OpenGitRepo(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	largest, err := repo.packedObjects(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(largest) != 1 || largest[0].Path != "big.txt" {
+		t.Errorf("largest object is %+v, want the last big.txt", largest)
+	}
+}
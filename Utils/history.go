@@ -0,0 +1,188 @@
+package Utils
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// HistoryRecord is a compact summary of one scan: the sizes of the
+// directories near the top of the tree, keyed by their path relative to the
+// scanned directory ("." is the directory itself)
+type HistoryRecord struct {
+	Path      string           `json:"path"`
+	ScannedAt time.Time        `json:"scannedAt"`
+	Depth     int              `json:"depth"`
+	Sizes     map[string]int64 `json:"sizes"`
+}
+
+// DirTrend is the size of one directory over a series of history records
+type DirTrend struct {
+	Path  string
+	Sizes []int64 // One per record, -1 where the directory was not recorded
+}
+
+// First returns the first recorded size of the directory
+func (t DirTrend) First() (int64, int) {
+	for i, size := range t.Sizes {
+		if size >= 0 {
+			return size, i
+		}
+	}
+	return 0, -1
+}
+
+// Last returns the last recorded size of the directory
+func (t DirTrend) Last() (int64, int) {
+	for i := len(t.Sizes) - 1; i >= 0; i-- {
+		if t.Sizes[i] >= 0 {
+			return t.Sizes[i], i
+		}
+	}
+	return 0, -1
+}
+
+// DefaultHistoryFile returns where snapshots are recorded when no file is
+// given: $XDG_DATA_HOME/disksizer/history.jsonl, falling back to
+// ~/.local/share
+func DefaultHistoryFile() string {
+	dataDir := os.Getenv("XDG_DATA_HOME")
+	if dataDir == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			dataDir = filepath.Join(home, ".local", "share")
+		}
+	}
+	return filepath.Join(dataDir, "disksizer", "history.jsonl")
+}
+
+// NewHistoryRecord summarizes a scan taken now, keeping the sizes of the
+// directories up to depth levels below root
+func NewHistoryRecord(root DirEntry, depth int) HistoryRecord {
+	record := HistoryRecord{
+		Path:      root.Path,
+		ScannedAt: time.Now(),
+		Depth:     depth,
+		Sizes:     make(map[string]int64),
+	}
+
+	WalkEntries(root, func(e DirEntry, level int) bool {
+		if !e.IsDir && level > 0 {
+			return false
+		}
+		rel, err := filepath.Rel(root.Path, e.Path)
+		if err != nil {
+			return false
+		}
+		record.Sizes[filepath.ToSlash(rel)] = e.Size
+		return level < depth
+	})
+	return record
+}
+
+// AppendHistory adds a record to the end of a history file, creating the
+// file and its directory if needed
+func AppendHistory(file string, record HistoryRecord) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadHistory reads the records of path from a history file, oldest first.
+// A missing file is an empty history.
+func LoadHistory(file, path string) ([]HistoryRecord, error) {
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []HistoryRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record HistoryRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid history record: %v", file, line, err)
+		}
+		if record.Path == path {
+			records = append(records, record)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].ScannedAt.Before(records[j].ScannedAt)
+	})
+	return records, nil
+}
+
+// HistoryTrends lines up the sizes of every directory that appears in the
+// records. Trends are sorted by path, so parents come before their children.
+func HistoryTrends(records []HistoryRecord) []DirTrend {
+	byPath := make(map[string]*DirTrend)
+	for i, record := range records {
+		for path, size := range record.Sizes {
+			trend, ok := byPath[path]
+			if !ok {
+				trend = &DirTrend{Path: path, Sizes: make([]int64, len(records))}
+				for j := range trend.Sizes {
+					trend.Sizes[j] = -1
+				}
+				byPath[path] = trend
+			}
+			trend.Sizes[i] = size
+		}
+	}
+
+	trends := make([]DirTrend, 0, len(byPath))
+	for _, trend := range byPath {
+		trends = append(trends, *trend)
+	}
+	sort.Slice(trends, func(i, j int) bool {
+		return trends[i].Path < trends[j].Path
+	})
+	return trends
+}
+
+// GrowthPerDay returns how many bytes a directory grew per day between its
+// first and last record, or 0 if those are less than a minute apart
+func GrowthPerDay(trend DirTrend, records []HistoryRecord) float64 {
+	first, i := trend.First()
+	last, j := trend.Last()
+	if i < 0 || i == j {
+		return 0
+	}
+
+	elapsed := records[j].ScannedAt.Sub(records[i].ScannedAt)
+	if elapsed < time.Minute {
+		return 0
+	}
+	return float64(last-first) / (float64(elapsed) / float64(Day))
+}
@@ -0,0 +1,462 @@
+package Utils
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ContainerImage is an image found in an OCI image layout or a tarball
+// written by `docker save`
+type ContainerImage struct {
+	Name     string   `json:"name"` // First tag, or <none>@ and the short digest
+	Tags     []string `json:"tags,omitempty"`
+	Platform string   `json:"platform,omitempty"` // e.g. linux/amd64, for images of multi-platform indexes
+	Blobs    []string `json:"blobs"`              // Names of its manifest, config and layers
+	Size     int64    `json:"size"`
+	Unique   int64    `json:"unique"` // Bytes only this image uses, freed by removing it
+	Shared   int64    `json:"shared"` // Bytes it shares with other images
+	key      string
+}
+
+// ImageBlob is a manifest, config or layer stored for one or more images
+type ImageBlob struct {
+	Name   string   `json:"name"`   // Path in the layout or tarball
+	Digest string   `json:"digest"` // Digest, or the layer ID of old `docker save` tarballs
+	Kind   string   `json:"kind"`
+	Size   int64    `json:"size"`
+	Images []string `json:"images"`
+}
+
+// ImageReport is the usage of the images in a layout or tarball
+type ImageReport struct {
+	Path   string           `json:"path"`
+	Images []ContainerImage `json:"images"` // Largest unique size first
+	Blobs  []ImageBlob      `json:"blobs"`  // Largest first
+	Total  int64            `json:"total"`  // Bytes of all blobs, shared ones counted once
+	Shared int64            `json:"shared"` // Bytes of blobs used by more than one image
+}
+
+// Kinds of image blobs
+const (
+	BlobIndex    = "index"
+	BlobManifest = "manifest"
+	BlobConfig   = "config"
+	BlobLayer    = "layer"
+)
+
+// SharedImageBlobs is the directory of the blobs shared between images in
+// the tree of an ImageReport
+const SharedImageBlobs = "(shared)"
+
+// ociDescriptor points to a blob of an OCI image layout
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Annotations map[string]string `json:"annotations"`
+	Platform    *struct {
+		OS           string `json:"os"`
+		Architecture string `json:"architecture"`
+		Variant      string `json:"variant"`
+	} `json:"platform"`
+}
+
+// ociManifest is an image manifest or, with Manifests set, an image index
+type ociManifest struct {
+	MediaType string          `json:"mediaType"`
+	Config    ociDescriptor   `json:"config"`
+	Layers    []ociDescriptor `json:"layers"`
+	Manifests []ociDescriptor `json:"manifests"`
+}
+
+// dockerManifest is an entry of the manifest.json of a `docker save` tarball
+type dockerManifest struct {
+	Config   string
+	RepoTags []string
+	Layers   []string
+}
+
+// imageSource is a layout directory or tarball holding images
+type imageSource interface {
+	// size returns the size of a file, false if it is not stored
+	size(name string) (int64, bool)
+	read(name string) ([]byte, error)
+}
+
+// dirImageSource reads an OCI image layout directory
+type dirImageSource string
+
+func (d dirImageSource) size(name string) (int64, bool) {
+	info, err := os.Stat(filepath.Join(string(d), filepath.FromSlash(name)))
+	if err != nil || !info.Mode().IsRegular() {
+		return 0, false
+	}
+	return info.Size(), true
+}
+
+func (d dirImageSource) read(name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(string(d), filepath.FromSlash(name)))
+}
+
+// maxImageDocument is the size up to which the files of a tarball are kept
+// in memory, which covers all JSON documents but not the layers
+const maxImageDocument = 1 << 20
+
+// tarImageSource holds the file sizes of a tarball and the contents of its
+// small files, so it is read only once
+type tarImageSource struct {
+	sizes    map[string]int64
+	contents map[string][]byte
+}
+
+func (t *tarImageSource) size(name string) (int64, bool) {
+	size, found := t.sizes[name]
+	return size, found
+}
+
+func (t *tarImageSource) read(name string) ([]byte, error) {
+	data, found := t.contents[name]
+	if !found {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+	return data, nil
+}
+
+// readImageTarball reads a .tar or .tar.gz written by `docker save`
+func readImageTarball(file string) (*tarImageSource, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var reader io.Reader = f
+	if archiveKind(file) == "tar.gz" {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	source := &tarImageSource{sizes: make(map[string]int64), contents: make(map[string][]byte)}
+	tr := tar.NewReader(reader)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+
+		name := archiveName(header.Name)
+		if name == "" || header.Typeflag != tar.TypeReg {
+			continue
+		}
+		source.sizes[name] = header.Size
+		if header.Size <= maxImageDocument {
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", file, err)
+			}
+			source.contents[name] = data
+		}
+	}
+	return source, nil
+}
+
+// imageReader collects the images of a source and the blobs they use
+type imageReader struct {
+	source imageSource
+	images []*ContainerImage
+	blobs  map[string]*ImageBlob
+}
+
+// ReadImages reads the images of an OCI image layout directory or of a
+// tarball written by `docker save`, without extracting anything. Blobs that
+// are not stored, e.g. those of other platforms, are left out.
+func ReadImages(file string) (ImageReport, error) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return ImageReport{}, err
+	}
+
+	var source imageSource
+	if info.IsDir() {
+		source = dirImageSource(file)
+	} else if kind := archiveKind(file); kind == "tar" || kind == "tar.gz" {
+		if source, err = readImageTarball(file); err != nil {
+			return ImageReport{}, err
+		}
+	} else {
+		return ImageReport{}, fmt.Errorf("%s is neither a directory nor a .tar or .tar.gz file", file)
+	}
+
+	r := &imageReader{source: source, blobs: make(map[string]*ImageBlob)}
+	if data, err := source.read("manifest.json"); err == nil {
+		err = r.readDockerManifest(data)
+		if err != nil {
+			return ImageReport{}, fmt.Errorf("error reading manifest.json: %v", err)
+		}
+	} else if data, err := source.read("index.json"); err == nil {
+		var index ociManifest
+		if err := json.Unmarshal(data, &index); err != nil {
+			return ImageReport{}, fmt.Errorf("error reading index.json: %v", err)
+		}
+		r.readIndex(index, "", nil)
+	} else {
+		return ImageReport{}, fmt.Errorf("%s is neither an OCI image layout nor a docker save tarball", file)
+	}
+
+	return r.report(file), nil
+}
+
+// blobName returns the name of the blob with a digest in an OCI layout
+func blobName(digest string) string {
+	algorithm, hex, ok := strings.Cut(digest, ":")
+	name := "blobs/" + algorithm + "/" + hex
+	if !ok || strings.Contains(hex, "/") || !fs.ValidPath(name) {
+		return ""
+	}
+	return name
+}
+
+// blobDigest returns the digest or ID of a blob from its name
+func blobDigest(name string) string {
+	if parts := strings.Split(name, "/"); len(parts) == 3 && parts[0] == "blobs" {
+		return parts[1] + ":" + parts[2]
+	}
+	if path.Base(name) == "layer.tar" {
+		return path.Dir(name)
+	}
+	return "sha256:" + strings.TrimSuffix(path.Base(name), ".json")
+}
+
+// shortDigest returns the first 12 hex digits of a digest
+func shortDigest(digest string) string {
+	_, hex, found := strings.Cut(digest, ":")
+	if !found {
+		hex = digest
+	}
+	return hex[:min(len(hex), 12)]
+}
+
+// image returns the image with the given key, adding it if it is new
+func (r *imageReader) image(key, tag, platform string) *ContainerImage {
+	for _, image := range r.images {
+		if image.key == key {
+			if tag != "" && !slices.Contains(image.Tags, tag) {
+				image.Tags = append(image.Tags, tag)
+			}
+			return image
+		}
+	}
+
+	image := &ContainerImage{key: key, Platform: platform}
+	if tag != "" {
+		image.Tags = []string{tag}
+	}
+	r.images = append(r.images, image)
+	return image
+}
+
+// addBlob records that an image uses a blob, if the blob is stored
+func (r *imageReader) addBlob(image *ContainerImage, name, kind string) {
+	if name == "" || slices.Contains(image.Blobs, name) {
+		return
+	}
+	if _, found := r.blobs[name]; !found {
+		size, stored := r.source.size(name)
+		if !stored {
+			return
+		}
+		r.blobs[name] = &ImageBlob{Name: name, Digest: blobDigest(name), Kind: kind, Size: size}
+	}
+	image.Blobs = append(image.Blobs, name)
+}
+
+// readDockerManifest reads the images listed in the manifest.json of a
+// `docker save` tarball
+func (r *imageReader) readDockerManifest(data []byte) error {
+	var manifests []dockerManifest
+	if err := json.Unmarshal(data, &manifests); err != nil {
+		return err
+	}
+
+	for _, manifest := range manifests {
+		image := r.image(manifest.Config, "", "")
+		for _, tag := range manifest.RepoTags {
+			r.image(manifest.Config, tag, "")
+		}
+		r.addBlob(image, path.Clean(manifest.Config), BlobConfig)
+		for _, layer := range manifest.Layers {
+			r.addBlob(image, path.Clean(layer), BlobLayer)
+		}
+	}
+	return nil
+}
+
+// readIndex reads the images of an OCI image index. tag is the name of the
+// index itself and parents are the blobs of the indexes it is nested in.
+func (r *imageReader) readIndex(index ociManifest, tag string, parents []string) {
+	for _, desc := range index.Manifests {
+		name := blobName(desc.Digest)
+		data, err := r.source.read(name)
+		if name == "" || err != nil {
+			continue
+		}
+		var manifest ociManifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			continue
+		}
+
+		ref := tag
+		if annotated := imageRefName(desc.Annotations); annotated != "" {
+			ref = annotated
+		}
+
+		if len(manifest.Manifests) > 0 {
+			r.readIndex(manifest, ref, append(parents[:len(parents):len(parents)], name))
+			continue
+		}
+
+		platform := ""
+		if desc.Platform != nil && len(index.Manifests) > 1 {
+			platform = desc.Platform.OS + "/" + desc.Platform.Architecture
+			if desc.Platform.Variant != "" {
+				platform += "/" + desc.Platform.Variant
+			}
+		}
+
+		image := r.image(desc.Digest, ref, platform)
+		for _, parent := range parents {
+			r.addBlob(image, parent, BlobIndex)
+		}
+		r.addBlob(image, name, BlobManifest)
+		r.addBlob(image, blobName(manifest.Config.Digest), BlobConfig)
+		for _, layer := range manifest.Layers {
+			r.addBlob(image, blobName(layer.Digest), BlobLayer)
+		}
+	}
+}
+
+// imageRefName returns the image name from the annotations of a manifest
+func imageRefName(annotations map[string]string) string {
+	if name := annotations["io.containerd.image.name"]; name != "" {
+		return name
+	}
+	return annotations["org.opencontainers.image.ref.name"]
+}
+
+// report names the images and adds up their sizes
+func (r *imageReader) report(file string) ImageReport {
+	report := ImageReport{Path: file}
+
+	users := make(map[string]int)
+	for _, image := range r.images {
+		for _, name := range image.Blobs {
+			users[name]++
+		}
+	}
+
+	for _, image := range r.images {
+		if len(image.Tags) > 0 {
+			image.Name = image.Tags[0]
+		} else {
+			digest := image.key
+			if !strings.Contains(digest, ":") {
+				digest = blobDigest(path.Clean(digest))
+			}
+			image.Name = "<none>@" + shortDigest(digest)
+		}
+		if image.Platform != "" {
+			image.Name += " (" + image.Platform + ")"
+		}
+
+		for _, name := range image.Blobs {
+			blob := r.blobs[name]
+			blob.Images = append(blob.Images, image.Name)
+			image.Size += blob.Size
+			if users[name] > 1 {
+				image.Shared += blob.Size
+			} else {
+				image.Unique += blob.Size
+			}
+		}
+		report.Images = append(report.Images, *image)
+	}
+
+	for _, blob := range r.blobs {
+		report.Blobs = append(report.Blobs, *blob)
+		report.Total += blob.Size
+		if len(blob.Images) > 1 {
+			report.Shared += blob.Size
+		}
+	}
+
+	sort.Slice(report.Images, func(i, j int) bool {
+		if report.Images[i].Unique != report.Images[j].Unique {
+			return report.Images[i].Unique > report.Images[j].Unique
+		}
+		return report.Images[i].Name < report.Images[j].Name
+	})
+	sort.Slice(report.Blobs, func(i, j int) bool {
+		if report.Blobs[i].Size != report.Blobs[j].Size {
+			return report.Blobs[i].Size > report.Blobs[j].Size
+		}
+		return report.Blobs[i].Name < report.Blobs[j].Name
+	})
+	return report
+}
+
+// FileSystem lays out the report as a tree: a directory per image with the
+// blobs only it uses, which removing it frees, and the SharedImageBlobs
+// directory with the blobs used by several images. Every blob is in the
+// tree once, so the sizes add up to the space the images take.
+func (report ImageReport) FileSystem() (*MemFileSystem, error) {
+	fsys := NewMemFileSystem()
+	blobs := make(map[string]ImageBlob, len(report.Blobs))
+	for _, blob := range report.Blobs {
+		blobs[blob.Name] = blob
+	}
+
+	for _, image := range report.Images {
+		// Repository names with slashes become nested directories
+		dir := image.Name
+		if image.Platform != "" {
+			name := strings.TrimSuffix(image.Name, " ("+image.Platform+")")
+			dir = name + " (" + strings.ReplaceAll(image.Platform, "/", "-") + ")"
+		}
+		dir = archiveName(dir)
+		if dir == "" {
+			continue
+		}
+		if err := fsys.AddDir(dir, time.Time{}); err != nil {
+			return nil, err
+		}
+
+		for _, name := range image.Blobs {
+			blob := blobs[name]
+			file := path.Join(dir, blob.Kind+" "+shortDigest(blob.Digest))
+			if len(blob.Images) > 1 {
+				file = path.Join(SharedImageBlobs, fmt.Sprintf("%s %s (%d images)", blob.Kind, shortDigest(blob.Digest), len(blob.Images)))
+			}
+			if err := fsys.AddFile(file, blob.Size, blob.Size, time.Time{}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return fsys, nil
+}
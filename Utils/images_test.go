@@ -0,0 +1,257 @@
+package Utils
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// ociLayout writes the blobs of an OCI image layout to dir
+type ociLayout struct {
+	t   *testing.T
+	dir string
+}
+
+// blob stores data and returns its descriptor
+func (l ociLayout) blob(data []byte) map[string]any {
+	l.t.Helper()
+	sum := sha256.Sum256(data)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	file := filepath.Join(l.dir, "blobs", "sha256", hex.EncodeToString(sum[:]))
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		l.t.Fatal(err)
+	}
+	if err := os.WriteFile(file, data, 0o644); err != nil {
+		l.t.Fatal(err)
+	}
+	return map[string]any{"digest": digest, "size": len(data)}
+}
+
+// json stores v as JSON and returns its descriptor
+func (l ociLayout) json(v any) map[string]any {
+	l.t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		l.t.Fatal(err)
+	}
+	return l.blob(data)
+}
+
+// manifest stores an image manifest with its config and returns its
+// descriptor
+func (l ociLayout) manifest(config string, layers ...map[string]any) map[string]any {
+	return l.json(map[string]any{
+		"mediaType": "application/vnd.oci.image.manifest.v1+json",
+		"config":    l.blob([]byte(config)),
+		"layers":    layers,
+	})
+}
+
+// with returns desc with the given fields added
+func with(desc map[string]any, fields map[string]any) map[string]any {
+	added := make(map[string]any, len(desc)+len(fields))
+	for key, value := range desc {
+		added[key] = value
+	}
+	for key, value := range fields {
+		added[key] = value
+	}
+	return added
+}
+
+// imagesByName returns the images of report by name
+func imagesByName(report ImageReport) map[string]ContainerImage {
+	images := make(map[string]ContainerImage)
+	for _, image := range report.Images {
+		images[image.Name] = image
+	}
+	return images
+}
+
+// blobSize returns the size of the stored blob of a descriptor
+func blobSize(desc map[string]any) int64 {
+	return int64(desc["size"].(int))
+}
+
+func TestReadImagesLayout(t *testing.T) {
+	dir := t.TempDir()
+	l := ociLayout{t, dir}
+
+	base := l.blob(bytes.Repeat([]byte("b"), 1000))
+	amd64 := l.manifest(`{"architecture":"amd64"}`, base, l.blob(bytes.Repeat([]byte("x"), 100)))
+	arm64 := l.manifest(`{"architecture":"arm64"}`, base, l.blob(bytes.Repeat([]byte("a"), 200)))
+	tool := l.manifest(`{"tool":true}`, base, l.blob(bytes.Repeat([]byte("t"), 300)))
+
+	// A multi-platform index nested in the index of the layout, with a
+	// platform whose manifest is not stored
+	platformIndex := l.json(map[string]any{
+		"mediaType": "application/vnd.oci.image.index.v1+json",
+		"manifests": []map[string]any{
+			with(amd64, map[string]any{"platform": map[string]string{"os": "linux", "architecture": "amd64"}}),
+			with(arm64, map[string]any{"platform": map[string]string{"os": "linux", "architecture": "arm64", "variant": "v8"}}),
+			{"digest": "sha256:" + strings.Repeat("0", 64), "platform": map[string]string{"os": "windows", "architecture": "amd64"}},
+		},
+	})
+	index, err := json.Marshal(map[string]any{
+		"manifests": []map[string]any{
+			with(platformIndex, map[string]any{"annotations": map[string]string{"org.opencontainers.image.ref.name": "app:1"}}),
+			with(tool, map[string]any{"annotations": map[string]string{"io.containerd.image.name": "example.com/tools/tool:2"}}),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "index.json"), index, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := ReadImages(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	images := imagesByName(report)
+	var names []string
+	for _, image := range report.Images {
+		names = append(names, image.Name)
+	}
+	sorted := append([]string{}, names...)
+	sort.Strings(sorted)
+	want := []string{"app:1 (linux/amd64)", "app:1 (linux/arm64/v8)", "example.com/tools/tool:2"}
+	if !reflect.DeepEqual(sorted, want) {
+		t.Fatalf("got images %v, want %v", names, want)
+	}
+
+	// The platform index is shared by its platforms and the base layer by
+	// every image
+	shared := blobSize(base) + blobSize(platformIndex)
+	amd := images["app:1 (linux/amd64)"]
+	if amd.Platform != "linux/amd64" || amd.Shared != shared || amd.Unique != amd.Size-shared {
+		t.Errorf("amd64 image is %+v, want %d bytes shared", amd, shared)
+	}
+	toolImage := images["example.com/tools/tool:2"]
+	if toolImage.Platform != "" || toolImage.Shared != blobSize(base) || toolImage.Unique != blobSize(tool)+int64(len(`{"tool":true}`))+300 {
+		t.Errorf("tool image is %+v", toolImage)
+	}
+
+	var total int64
+	for _, blob := range report.Blobs {
+		total += blob.Size
+	}
+	if report.Total != total || report.Shared != shared {
+		t.Errorf("total %d and shared %d, want %d and %d", report.Total, report.Shared, total, shared)
+	}
+	// Images with the largest unique size come first
+	if report.Images[0].Name != "example.com/tools/tool:2" || report.Images[2].Name != "app:1 (linux/amd64)" {
+		t.Errorf("images are in the order %v, want the tool first and amd64 last", names)
+	}
+
+	checkImageFileSystem(t, report)
+}
+
+func TestReadImagesDockerSave(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	files := []struct {
+		name string
+		data string
+	}{
+		{"manifest.json", `[
+			{"Config": "1111111111111111.json", "RepoTags": ["app:1", "app:latest"], "Layers": ["l1/layer.tar", "l2/layer.tar"]},
+			{"Config": "2222222222222222.json", "Layers": ["l1/layer.tar", "./l3/layer.tar"]}
+		]`},
+		{"1111111111111111.json", "{}"},
+		{"2222222222222222.json", "{ }"},
+		{"l1/layer.tar", strings.Repeat("1", 1000)},
+		{"l2/layer.tar", strings.Repeat("2", 200)},
+		{"l3/layer.tar", strings.Repeat("3", 300)},
+	}
+	for _, file := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: file.name, Mode: 0o644, Size: int64(len(file.data)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(file.data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "images.tar")
+	if err := os.WriteFile(file, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := ReadImages(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	images := imagesByName(report)
+	tagged, untagged := images["app:1"], images["<none>@222222222222"]
+	if len(images) != 2 || !reflect.DeepEqual(tagged.Tags, []string{"app:1", "app:latest"}) {
+		t.Fatalf("got images %+v", report.Images)
+	}
+	if tagged.Size != 1202 || tagged.Shared != 1000 || tagged.Unique != 202 {
+		t.Errorf("tagged image is %+v, want 1202 bytes with 1000 shared", tagged)
+	}
+	if untagged.Size != 1303 || untagged.Shared != 1000 || untagged.Unique != 303 {
+		t.Errorf("untagged image is %+v, want 1303 bytes with 1000 shared", untagged)
+	}
+	if report.Total != 1505 || report.Shared != 1000 {
+		t.Errorf("total %d and shared %d, want 1505 and 1000", report.Total, report.Shared)
+	}
+
+	checkImageFileSystem(t, report)
+}
+
+// checkImageFileSystem checks that the tree of report holds every blob once
+func checkImageFileSystem(t *testing.T, report ImageReport) {
+	t.Helper()
+	fsys, err := report.FileSystem()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var files int
+	var total int64
+	err = fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files++
+		total += info.Size()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files != len(report.Blobs) || total != report.Total {
+		t.Errorf("tree has %d files of %d bytes, want %d blobs of %d bytes", files, total, len(report.Blobs), report.Total)
+	}
+	if report.Shared > 0 {
+		if _, err := fsys.Stat(SharedImageBlobs); err != nil {
+			t.Errorf("no shared blobs directory: %v", err)
+		}
+	}
+	for _, image := range report.Images {
+		if image.Platform == "" {
+			continue
+		}
+		dir := strings.TrimSuffix(image.Name, " ("+image.Platform+")") + " (" + strings.ReplaceAll(image.Platform, "/", "-") + ")"
+		if _, err := fsys.Stat(dir); err != nil {
+			t.Errorf("no directory for %s: %v", image.Name, err)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package Utils
+
+import (
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemFileSystem is a FileSystem held in memory, e.g. as a fake disk or for
+// the index of an archive. Files have a size but no contents, reading them
+// returns nothing.
+type MemFileSystem struct {
+	mu   sync.RWMutex
+	root *memNode
+}
+
+// memNode is a file, directory or symlink of a MemFileSystem
+type memNode struct {
+	name     string
+	mode     fs.FileMode
+	size     int64
+	stored   int64
+	modTime  time.Time
+	children map[string]*memNode
+}
+
+// NewMemFileSystem returns an empty in-memory file system
+func NewMemFileSystem() *MemFileSystem {
+	return &MemFileSystem{root: newMemDir(".", time.Time{})}
+}
+
+func newMemDir(name string, modTime time.Time) *memNode {
+	return &memNode{name: name, mode: fs.ModeDir | 0o755, modTime: modTime, children: make(map[string]*memNode)}
+}
+
+// AddDir adds a directory and any missing parents
+func (m *MemFileSystem) AddDir(name string, modTime time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	dir, err := m.mkdirAll(name, "mkdir")
+	if err != nil {
+		return err
+	}
+	dir.modTime = modTime
+	return nil
+}
+
+// AddFile adds a file of size bytes that takes up stored bytes in storage,
+// creating missing parent directories. An existing file is replaced.
+func (m *MemFileSystem) AddFile(name string, size, stored int64, modTime time.Time) error {
+	return m.add(name, &memNode{mode: 0o644, size: size, stored: stored, modTime: modTime})
+}
+
+// AddSymlink adds a symlink, which the scanner skips like on disk
+func (m *MemFileSystem) AddSymlink(name string, modTime time.Time) error {
+	return m.add(name, &memNode{mode: fs.ModeSymlink | 0o777, modTime: modTime})
+}
+
+func (m *MemFileSystem) add(name string, node *memNode) error {
+	if !fs.ValidPath(name) || name == "." {
+		return &fs.PathError{Op: "create", Path: name, Err: fs.ErrInvalid}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	parent, err := m.mkdirAll(path.Dir(name), "create")
+	if err != nil {
+		return err
+	}
+	node.name = path.Base(name)
+	if existing, ok := parent.children[node.name]; ok && existing.mode.IsDir() {
+		return &fs.PathError{Op: "create", Path: name, Err: fs.ErrExist}
+	}
+	parent.children[node.name] = node
+	return nil
+}
+
+// mkdirAll returns the directory name, creating it and its parents as needed
+func (m *MemFileSystem) mkdirAll(name, op string) (*memNode, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	dir := m.root
+	if name == "." {
+		return dir, nil
+	}
+	for _, part := range strings.Split(name, "/") {
+		child, ok := dir.children[part]
+		if !ok {
+			child = newMemDir(part, time.Time{})
+			dir.children[part] = child
+		} else if !child.mode.IsDir() {
+			return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrExist}
+		}
+		dir = child
+	}
+	return dir, nil
+}
+
+// lookup returns the node for name
+func (m *MemFileSystem) lookup(op, name string) (*memNode, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	node := m.root
+	if name == "." {
+		return node, nil
+	}
+	for _, part := range strings.Split(name, "/") {
+		child, ok := node.children[part]
+		if !ok {
+			return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+		}
+		node = child
+	}
+	return node, nil
+}
+
+func (m *MemFileSystem) Open(name string) (fs.File, error) {
+	node, err := m.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+	return &memFile{node: node, fsys: m}, nil
+}
+
+func (m *MemFileSystem) ReadDir(name string) ([]fs.DirEntry, error) {
+	node, err := m.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if !node.mode.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	return m.entries(node), nil
+}
+
+// Stat is the same as Lstat, symlinks have no targets in memory
+func (m *MemFileSystem) Stat(name string) (fs.FileInfo, error) {
+	return m.Lstat(name)
+}
+
+func (m *MemFileSystem) Lstat(name string) (fs.FileInfo, error) {
+	node, err := m.lookup("lstat", name)
+	if err != nil {
+		return nil, err
+	}
+	return memInfo{node}, nil
+}
+
+func (m *MemFileSystem) LstatBlocks(name string) (fs.FileInfo, int64, error) {
+	node, err := m.lookup("lstat", name)
+	if err != nil {
+		return nil, 0, err
+	}
+	return memInfo{node}, node.stored, nil
+}
+
+// Sub returns the file system below dir. It shares the files with m, which
+// must not change while the result is in use.
+func (m *MemFileSystem) Sub(dir string) (*MemFileSystem, error) {
+	node, err := m.lookup("sub", dir)
+	if err != nil {
+		return nil, err
+	}
+	if !node.mode.IsDir() {
+		return nil, &fs.PathError{Op: "sub", Path: dir, Err: fs.ErrInvalid}
+	}
+	return &MemFileSystem{root: node}, nil
+}
+
+// entries returns the children of a directory sorted by name
+func (m *MemFileSystem) entries(dir *memNode) []fs.DirEntry {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entries := make([]fs.DirEntry, 0, len(dir.children))
+	for _, child := range dir.children {
+		entries = append(entries, memInfo{child})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries
+}
+
+// memInfo describes a node as fs.FileInfo and fs.DirEntry
+type memInfo struct {
+	node *memNode
+}
+
+func (i memInfo) Name() string               { return i.node.name }
+func (i memInfo) Size() int64                { return i.node.size }
+func (i memInfo) Mode() fs.FileMode          { return i.node.mode }
+func (i memInfo) ModTime() time.Time         { return i.node.modTime }
+func (i memInfo) IsDir() bool                { return i.node.mode.IsDir() }
+func (i memInfo) Sys() any                   { return nil }
+func (i memInfo) Type() fs.FileMode          { return i.node.mode.Type() }
+func (i memInfo) Info() (fs.FileInfo, error) { return i, nil }
+
+// memFile is an open node of a MemFileSystem
+type memFile struct {
+	node    *memNode
+	fsys    *MemFileSystem
+	entries []fs.DirEntry
+	read    bool
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) { return memInfo{f.node}, nil }
+func (f *memFile) Read(b []byte) (int, error) { return 0, io.EOF }
+func (f *memFile) Close() error               { return nil }
+
+// ReadDir implements fs.ReadDirFile
+func (f *memFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !f.node.mode.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: f.node.name, Err: fs.ErrInvalid}
+	}
+	if !f.read {
+		f.entries = f.fsys.entries(f.node)
+		f.read = true
+	}
+
+	if n <= 0 {
+		entries := f.entries
+		f.entries = nil
+		return entries, nil
+	}
+	if len(f.entries) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(f.entries))
+	entries := f.entries[:n]
+	f.entries = f.entries[n:]
+	return entries, nil
+}
@@ -0,0 +1,259 @@
+package Utils
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+// DirMetric is the size and file count of one directory
+type DirMetric struct {
+	Path  string
+	Size  int64
+	Files int64
+}
+
+// rootMetrics is what the last scans of one configured path found
+type rootMetrics struct {
+	dirs        []DirMetric
+	duration    time.Duration
+	skipped     int64
+	lastScan    time.Time
+	scans       int64
+	scanErrors  int64
+	lastSuccess bool
+}
+
+// MetricsExporter periodically scans a set of paths and writes what it
+// found in the Prometheus text format
+type MetricsExporter struct {
+	paths []string
+	depth int
+
+	mu    sync.Mutex
+	roots map[string]*rootMetrics
+}
+
+// NewMetricsExporter creates an exporter that reports the directories up to
+// depth levels below each of paths
+func NewMetricsExporter(paths []string, depth int) *MetricsExporter {
+	e := &MetricsExporter{
+		paths: paths,
+		depth: depth,
+		roots: make(map[string]*rootMetrics),
+	}
+	for _, path := range paths {
+		e.roots[path] = &rootMetrics{}
+	}
+	return e
+}
+
+// Run scans all paths right away and then every interval until stop is
+// closed
+func (e *MetricsExporter) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for _, path := range e.paths {
+			e.Scan(path)
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Scan rescans one of the paths and replaces its metrics
+func (e *MetricsExporter) Scan(path string) {
+	var processedSize int64
+	start := time.Now()
+	root, skipped, err := ScanDirToDepth(path, e.depth, &processedSize)
+	duration := time.Since(start)
+
+	var dirs []DirMetric
+	if err == nil {
+		collectDirMetrics(root, 0, e.depth, &dirs)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	metrics := e.roots[path]
+	metrics.scans++
+	metrics.lastScan = time.Now()
+	metrics.duration = duration
+	metrics.lastSuccess = err == nil
+	if err != nil {
+		// Keep reporting the sizes of the last successful scan
+		metrics.scanErrors++
+		return
+	}
+	metrics.dirs = dirs
+	metrics.skipped = skipped
+}
+
+// collectDirMetrics records the directories up to maxDepth levels below
+// entry and returns the number of files below it. Directories scanned by
+// ScanDirToDepth without their children count their files themselves.
+func collectDirMetrics(entry DirEntry, depth, maxDepth int, dirs *[]DirMetric) int64 {
+	if !entry.IsDir {
+		return 1
+	}
+
+	files := entry.Files
+	for _, child := range entry.Children {
+		files += collectDirMetrics(child, depth+1, maxDepth, dirs)
+	}
+	if depth <= maxDepth {
+		*dirs = append(*dirs, DirMetric{Path: entry.Path, Size: entry.Size, Files: files})
+	}
+	return files
+}
+
+// WriteMetrics writes the metrics of the last scans and the current
+// partition usage in the Prometheus text format
+func (e *MetricsExporter) WriteMetrics(w io.Writer) error {
+	var b strings.Builder
+
+	e.mu.Lock()
+	// Overlapping paths such as /var and /var/log both report the
+	// directories they share, which are written once from the first path
+	var dirs []DirMetric
+	seen := make(map[string]bool)
+	for _, path := range e.paths {
+		for _, dir := range e.roots[path].dirs {
+			if !seen[dir.Path] {
+				seen[dir.Path] = true
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	sort.Slice(dirs, func(i, j int) bool { return dirs[i].Path < dirs[j].Path })
+
+	writeMetricHeader(&b, "disksizer_directory_size_bytes", "gauge", "Total size of the files below a directory.")
+	for _, dir := range dirs {
+		writeMetric(&b, "disksizer_directory_size_bytes", float64(dir.Size), "path", dir.Path)
+	}
+	writeMetricHeader(&b, "disksizer_directory_files", "gauge", "Number of files below a directory.")
+	for _, dir := range dirs {
+		writeMetric(&b, "disksizer_directory_files", float64(dir.Files), "path", dir.Path)
+	}
+
+	rootMetricsOf := []struct {
+		name, kind, help string
+		value            func(m *rootMetrics) float64
+	}{
+		{"disksizer_scan_duration_seconds", "gauge", "Duration of the last scan.",
+			func(m *rootMetrics) float64 { return m.duration.Seconds() }},
+		{"disksizer_scan_skipped_bytes", "gauge", "Bytes that could not be read during the last successful scan.",
+			func(m *rootMetrics) float64 { return float64(m.skipped) }},
+		{"disksizer_scan_success", "gauge", "Whether the last scan succeeded.",
+			func(m *rootMetrics) float64 { return boolMetric(m.lastSuccess) }},
+		{"disksizer_scan_timestamp_seconds", "gauge", "Unix time of the last scan.",
+			func(m *rootMetrics) float64 { return float64(m.lastScan.UnixMilli()) / 1000 }},
+		{"disksizer_scans_total", "counter", "Number of scans.",
+			func(m *rootMetrics) float64 { return float64(m.scans) }},
+		{"disksizer_scan_errors_total", "counter", "Number of scans that failed.",
+			func(m *rootMetrics) float64 { return float64(m.scanErrors) }},
+	}
+	for _, metric := range rootMetricsOf {
+		writeMetricHeader(&b, metric.name, metric.kind, metric.help)
+		for _, path := range e.paths {
+			if m := e.roots[path]; m.scans > 0 {
+				writeMetric(&b, metric.name, metric.value(m), "path", path)
+			}
+		}
+	}
+	e.mu.Unlock()
+
+	writePartitionMetrics(&b)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writePartitionMetrics writes the usage of the mounted partitions, the same
+// ones shown in the TUI header
+func writePartitionMetrics(b *strings.Builder) {
+	partitions, err := disk.Partitions(false)
+	if err != nil {
+		return
+	}
+
+	type partitionUsage struct {
+		mountpoint, device, fstype string
+		usage                      *disk.UsageStat
+	}
+	var usages []partitionUsage
+	for _, p := range partitions {
+		usage, err := disk.Usage(p.Mountpoint)
+		if err != nil {
+			continue
+		}
+		usages = append(usages, partitionUsage{p.Mountpoint, p.Device, p.Fstype, usage})
+	}
+
+	partitionMetrics := []struct {
+		name, help string
+		value      func(u *disk.UsageStat) float64
+	}{
+		{"disksizer_partition_size_bytes", "Total size of a partition.",
+			func(u *disk.UsageStat) float64 { return float64(u.Total) }},
+		{"disksizer_partition_used_bytes", "Used space of a partition.",
+			func(u *disk.UsageStat) float64 { return float64(u.Used) }},
+		{"disksizer_partition_free_bytes", "Free space of a partition.",
+			func(u *disk.UsageStat) float64 { return float64(u.Free) }},
+		{"disksizer_partition_used_ratio", "Used space of a partition as a ratio of its size.",
+			func(u *disk.UsageStat) float64 { return u.UsedPercent / 100 }},
+	}
+	for _, metric := range partitionMetrics {
+		writeMetricHeader(b, metric.name, "gauge", metric.help)
+		for _, u := range usages {
+			writeMetric(b, metric.name, metric.value(u.usage),
+				"mountpoint", u.mountpoint, "device", u.device, "fstype", u.fstype)
+		}
+	}
+}
+
+func writeMetricHeader(b *strings.Builder, name, kind, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// writeMetric writes one sample, labels are given as name, value pairs
+func writeMetric(b *strings.Builder, name string, value float64, labels ...string) {
+	b.WriteString(name)
+	if len(labels) > 0 {
+		b.WriteString("{")
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			fmt.Fprintf(b, "%s=\"%s\"", labels[i], escapeLabel(labels[i+1]))
+		}
+		b.WriteString("}")
+	}
+	fmt.Fprintf(b, " %g\n", value)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabel escapes a label value for the Prometheus text format
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+func boolMetric(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,34 @@
+package Utils
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteMetricsOverlappingPaths(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"var/log/syslog": "log",
+		"var/lib/db":     "db",
+	})
+	varDir := filepath.Join(dir, "var")
+	logDir := filepath.Join(varDir, "log")
+
+	exporter := NewMetricsExporter([]string{varDir, logDir}, 1)
+	exporter.Scan(varDir)
+	exporter.Scan(logDir)
+
+	var b strings.Builder
+	if err := exporter.WriteMetrics(&b); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`disksizer_directory_files{path="` + escapeLabel(logDir) + `"} 1`,
+		`disksizer_directory_size_bytes{path="` + escapeLabel(varDir) + `"} 5`,
+	} {
+		if count := strings.Count(b.String(), want+"\n"); count != 1 {
+			t.Errorf("%s is written %d times, want once:\n%s", want, count, b.String())
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package Utils
+
+import (
+	"os/user"
+	"strconv"
+	"sync"
+)
+
+// UnknownOwner is used as uid and gid when the owner of a file is not known
+const UnknownOwner = -1
+
+// Resolved user and group names by id
+var (
+	ownerNames   = make(map[string]string)
+	ownerNamesMu sync.Mutex
+)
+
+// UserName resolves a uid to a user name, falling back to the number
+func UserName(uid int) string {
+	return resolveOwnerName("u", uid, func(id string) (string, error) {
+		u, err := user.LookupId(id)
+		if err != nil {
+			return "", err
+		}
+		return u.Username, nil
+	})
+}
+
+// GroupName resolves a gid to a group name, falling back to the number
+func GroupName(gid int) string {
+	return resolveOwnerName("g", gid, func(id string) (string, error) {
+		g, err := user.LookupGroupId(id)
+		if err != nil {
+			return "", err
+		}
+		return g.Name, nil
+	})
+}
+
+func resolveOwnerName(kind string, id int, lookup func(id string) (string, error)) string {
+	if id == UnknownOwner {
+		return "unknown"
+	}
+
+	key := kind + strconv.Itoa(id)
+	ownerNamesMu.Lock()
+	defer ownerNamesMu.Unlock()
+	if name, ok := ownerNames[key]; ok {
+		return name
+	}
+
+	name, err := lookup(strconv.Itoa(id))
+	if err != nil || name == "" {
+		name = strconv.Itoa(id)
+	}
+	ownerNames[key] = name
+	return name
+}
+
+// OwnerBreakdown sums the size and number of files below entry per user and
+// per group
+func OwnerBreakdown(entry DirEntry) (byUser, byGroup []UsageStat) {
+	users := make(usageCounter)
+	groups := make(usageCounter)
+
+	WalkEntries(entry, func(e DirEntry, depth int) bool {
+		if e.IsDir {
+			return true
+		}
+		users.add(UserName(e.UID), e.Size)
+		groups.add(GroupName(e.GID), e.Size)
+		return true
+	})
+
+	return users.list(), groups.list()
+}
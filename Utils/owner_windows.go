@@ -0,0 +1,10 @@
+//go:build windows
+
+package Utils
+
+import "os"
+
+// fileOwner returns UnknownOwner as files have no uid and gid on Windows
+func fileOwner(info os.FileInfo) (uid, gid int) {
+	return UnknownOwner, UnknownOwner
+}
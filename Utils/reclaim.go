@@ -0,0 +1,311 @@
+package Utils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ReclaimRule recognizes directories whose space can be reclaimed, such as
+// caches and build output that are recreated when needed. A directory
+// matches if its name or path matches one of the patterns and, if markers
+// are given, one of them exists next to it.
+type ReclaimRule struct {
+	Name          string   `yaml:"name" json:"name"`
+	Names         []string `yaml:"names,omitempty" json:"names,omitempty"`                 // Glob patterns for the directory name
+	Paths         []string `yaml:"paths,omitempty" json:"paths,omitempty"`                 // Glob patterns for the full path, ~ is the home directory
+	Markers       []string `yaml:"markers,omitempty" json:"markers,omitempty"`             // Files one of which must be next to the directory
+	OlderThanDays int      `yaml:"olderThanDays,omitempty" json:"olderThanDays,omitempty"` // Only files not modified for this long are reclaimable
+	Command       string   `yaml:"command,omitempty" json:"command,omitempty"`             // Cleans up instead of deleting, run by the shell in each matched directory
+	Disabled      bool     `yaml:"disabled,omitempty" json:"-"`                            // Turns off the built-in rule of the same name
+}
+
+// ReclaimConfig is the rules file, whose rules are checked before the
+// built-in ones
+type ReclaimConfig struct {
+	Rules []ReclaimRule `yaml:"rules"`
+}
+
+// ReclaimMatch is a directory matched by a rule
+type ReclaimMatch struct {
+	Rule  string   `json:"rule"`
+	Path  string   `json:"path"`
+	Size  int64    `json:"size"`            // Reclaimable bytes
+	Files []string `json:"files,omitempty"` // The old files, for rules with OlderThanDays
+}
+
+// DefaultReclaimRules returns the built-in rules. More specific rules come
+// first, as the first matching rule wins.
+func DefaultReclaimRules() []ReclaimRule {
+	return []ReclaimRule{
+		{Name: "go-build-cache", Paths: []string{"~/.cache/go-build", "~/Library/Caches/go-build", "~/AppData/Local/go-build"}, Command: "go clean -cache"},
+		{Name: "go-module-cache", Paths: []string{"~/go/pkg/mod"}, Command: "go clean -modcache"},
+		{Name: "npm-cache", Paths: []string{"~/.npm/_cacache", "~/AppData/Local/npm-cache"}},
+		{Name: "yarn-cache", Paths: []string{"~/.cache/yarn", "~/Library/Caches/Yarn", "~/AppData/Local/Yarn/Cache"}},
+		{Name: "pip-cache", Paths: []string{"~/.cache/pip", "~/Library/Caches/pip", "~/AppData/Local/pip/cache"}},
+		{Name: "cargo-registry", Paths: []string{"~/.cargo/registry"}},
+		{Name: "maven-repository", Paths: []string{"~/.m2/repository"}},
+		{Name: "gradle-cache", Paths: []string{"~/.gradle/caches"}},
+		{Name: "apt-cache", Paths: []string{"/var/cache/apt/archives"}, Command: "apt-get clean"},
+		{Name: "old-journal-logs", Paths: []string{"/var/log/journal/*"}, OlderThanDays: 30, Command: "journalctl --vacuum-time=30d"},
+		{Name: "user-cache", Paths: []string{"~/.cache/*", "~/Library/Caches/*"}},
+		{Name: "node_modules", Names: []string{"node_modules"}, Markers: []string{"package.json"}},
+		{Name: "python-bytecode", Names: []string{"__pycache__"}},
+		{Name: "gradle-project-cache", Names: []string{".gradle"}, Markers: []string{"build.gradle", "build.gradle.kts", "settings.gradle", "settings.gradle.kts"}},
+		{Name: "build-target", Names: []string{"target"}, Markers: []string{"Cargo.toml", "pom.xml"}},
+	}
+}
+
+// DefaultReclaimFile returns the path of the user's rules file
+func DefaultReclaimFile() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		configDir = "."
+	}
+	return filepath.Join(configDir, "disksizer", "reclaim.yaml")
+}
+
+// LoadReclaimRules returns the rules of file followed by the built-in ones
+// it does not replace or disable. A missing file only gives the built-in
+// rules.
+func LoadReclaimRules(file string) ([]ReclaimRule, error) {
+	var config ReclaimConfig
+
+	f, err := os.Open(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return DefaultReclaimRules(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+
+	var rules []ReclaimRule
+	replaced := make(map[string]bool)
+	for i, rule := range config.Rules {
+		if rule.Name == "" {
+			return nil, fmt.Errorf("%s: rule %d: name is required", file, i+1)
+		}
+		replaced[rule.Name] = true
+		if rule.Disabled {
+			continue
+		}
+		if err := validateReclaimRule(rule); err != nil {
+			return nil, fmt.Errorf("%s: rule %s: %v", file, rule.Name, err)
+		}
+		rules = append(rules, rule)
+	}
+
+	for _, rule := range DefaultReclaimRules() {
+		if !replaced[rule.Name] {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+// validateReclaimRule checks that a rule can match something
+func validateReclaimRule(rule ReclaimRule) error {
+	if len(rule.Names) == 0 && len(rule.Paths) == 0 {
+		return fmt.Errorf("names or paths are required")
+	}
+	for _, pattern := range append(append([]string{}, rule.Names...), rule.Paths...) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q", pattern)
+		}
+	}
+	if rule.OlderThanDays < 0 {
+		return fmt.Errorf("olderThanDays must not be negative")
+	}
+	return nil
+}
+
+// Matches reports whether the rule matches the directory at path
+func (rule ReclaimRule) Matches(path string) bool {
+	matched := false
+	name := filepath.Base(path)
+	for _, pattern := range rule.Names {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			matched = true
+			break
+		}
+	}
+	for _, pattern := range rule.Paths {
+		if matched {
+			break
+		}
+		pattern = filepath.FromSlash(ExpandHome(pattern))
+		if ok, _ := filepath.Match(pattern, path); ok {
+			matched = true
+		}
+	}
+	if !matched || len(rule.Markers) == 0 {
+		return matched
+	}
+
+	for _, marker := range rule.Markers {
+		if _, err := os.Stat(filepath.Join(filepath.Dir(path), marker)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchReclaimRule returns the first rule matching the directory at path
+func MatchReclaimRule(rules []ReclaimRule, path string) (ReclaimRule, bool) {
+	for _, rule := range rules {
+		if rule.Matches(path) {
+			return rule, true
+		}
+	}
+	return ReclaimRule{}, false
+}
+
+// FindReclaimable walks the scan result below entry and returns the
+// directories matched by rules, largest first. Matched directories are not
+// searched any further.
+func FindReclaimable(entry DirEntry, rules []ReclaimRule) []ReclaimMatch {
+	var matches []ReclaimMatch
+	var walk func(entry DirEntry)
+	walk = func(entry DirEntry) {
+		for _, child := range entry.Children {
+			if !child.IsDir {
+				continue
+			}
+			rule, found := MatchReclaimRule(rules, child.Path)
+			if !found {
+				walk(child)
+				continue
+			}
+
+			match := ReclaimMatch{Rule: rule.Name, Path: child.Path, Size: child.Size}
+			if rule.OlderThanDays > 0 {
+				match.Size = 0
+				cutoff := time.Now().AddDate(0, 0, -rule.OlderThanDays)
+				collectOldFiles(child, cutoff, &match)
+			}
+			if match.Size > 0 {
+				matches = append(matches, match)
+			}
+		}
+	}
+	walk(entry)
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Size > matches[j].Size
+	})
+	return matches
+}
+
+// collectOldFiles adds the files below entry modified before cutoff to match
+func collectOldFiles(entry DirEntry, cutoff time.Time, match *ReclaimMatch) {
+	for _, child := range entry.Children {
+		if child.IsDir {
+			collectOldFiles(child, cutoff, match)
+		} else if child.ModTime.Before(cutoff) {
+			match.Files = append(match.Files, child.Path)
+			match.Size += child.Size
+		}
+	}
+}
+
+// ReclaimTotals returns the number of matches and reclaimable bytes per rule,
+// largest first
+func ReclaimTotals(matches []ReclaimMatch) []UsageStat {
+	totals := make(map[string]*UsageStat)
+	for _, match := range matches {
+		stat, found := totals[match.Rule]
+		if !found {
+			stat = &UsageStat{Key: match.Rule}
+			totals[match.Rule] = stat
+		}
+		stat.Count++
+		stat.Size += match.Size
+	}
+
+	stats := make([]UsageStat, 0, len(totals))
+	for _, stat := range totals {
+		stats = append(stats, *stat)
+	}
+	SortUsageStats(stats, SortBySize)
+	return stats
+}
+
+// CleanReclaimable frees the space of matches. The command of a rule is run
+// in each matched directory, otherwise the matched directories, or their
+// old files, are deleted. It returns how many matches were cleaned.
+func CleanReclaimable(rules []ReclaimRule, matches []ReclaimMatch) (int, error) {
+	byName := make(map[string]ReclaimRule, len(rules))
+	for _, rule := range rules {
+		byName[rule.Name] = rule
+	}
+
+	cleaned := 0
+	for _, match := range matches {
+		rule := byName[match.Rule]
+		switch {
+		case rule.Command != "":
+			cmd := shellCommand(rule.Command)
+			cmd.Dir = match.Path
+			if output, err := cmd.CombinedOutput(); err != nil {
+				return cleaned, fmt.Errorf("%s in %s: %v: %s", rule.Command, match.Path, err, strings.TrimSpace(string(output)))
+			}
+		case rule.OlderThanDays > 0:
+			cutoff := time.Now().AddDate(0, 0, -rule.OlderThanDays)
+			if err := removeOldFiles(match.Files, cutoff); err != nil {
+				return cleaned, err
+			}
+		default:
+			if err := os.RemoveAll(match.Path); err != nil {
+				return cleaned, err
+			}
+		}
+		cleaned++
+	}
+	return cleaned, nil
+}
+
+// removeOldFiles removes the files that are still regular files modified
+// before cutoff. Files written to since they were scanned are kept.
+func removeOldFiles(files []string, cutoff time.Time) error {
+	for _, file := range files {
+		info, err := os.Lstat(file)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// shellCommand runs command with the shell of the platform
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+	return exec.Command("sh", "-c", command)
+}
@@ -0,0 +1,181 @@
+package Utils
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ruleNames returns the names of rules
+func ruleNames(rules []ReclaimRule) []string {
+	var names []string
+	for _, rule := range rules {
+		names = append(names, rule.Name)
+	}
+	return names
+}
+
+func TestReclaimRuleMatches(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("USERPROFILE", dir)
+	writeFiles(t, dir, map[string]string{
+		"web/package.json":          "{}",
+		"web/node_modules/x.js":     "x",
+		"loose/node_modules/x.js":   "x",
+		"rust/Cargo.toml":           "",
+		"rust/target/debug/app":     "app",
+		"java/build.gradle":         "",
+		"java/target/classes/A":     "a",
+		".cache/pip/wheel":          "w",
+		"src/pkg/__pycache__/a.pyc": "a",
+	})
+
+	rules := DefaultReclaimRules()
+	tests := []struct {
+		path string
+		rule string
+	}{
+		// Markers must be next to the directory
+		{"web/node_modules", "node_modules"},
+		{"loose/node_modules", ""},
+		{"rust/target", "build-target"},
+		{"java/target", ""},
+		// Paths below the home directory
+		{".cache/pip", "pip-cache"},
+		{"src/pkg/__pycache__", "python-bytecode"},
+		{"src/pkg", ""},
+	}
+	for _, test := range tests {
+		rule, found := MatchReclaimRule(rules, filepath.Join(dir, filepath.FromSlash(test.path)))
+		if rule.Name != test.rule || found != (test.rule != "") {
+			t.Errorf("%s matched %q, want %q", test.path, rule.Name, test.rule)
+		}
+	}
+}
+
+func TestMatchReclaimRuleOrder(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("USERPROFILE", dir)
+	path := filepath.Join(dir, ".cache", "pip")
+
+	// The more specific pip-cache comes before user-cache
+	if rule, _ := MatchReclaimRule(DefaultReclaimRules(), path); rule.Name != "pip-cache" {
+		t.Errorf("%s matched %q, want pip-cache", path, rule.Name)
+	}
+
+	rules := []ReclaimRule{
+		{Name: "first", Names: []string{"p*"}},
+		{Name: "second", Names: []string{"pip"}},
+	}
+	if rule, _ := MatchReclaimRule(rules, path); rule.Name != "first" {
+		t.Errorf("%s matched %q, want first", path, rule.Name)
+	}
+}
+
+func TestLoadReclaimRules(t *testing.T) {
+	dir := t.TempDir()
+	defaults := ruleNames(DefaultReclaimRules())
+
+	rules, err := LoadReclaimRules(filepath.Join(dir, "missing.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := ruleNames(rules); !reflect.DeepEqual(names, defaults) {
+		t.Errorf("a missing file gave %v, want the built-in rules", names)
+	}
+
+	file := filepath.Join(dir, "reclaim.yaml")
+	config := `rules:
+  - name: venv
+    names: [".venv"]
+    markers: [pyproject.toml]
+  - name: npm-cache
+    paths: ["/srv/npm"]
+  - name: node_modules
+    disabled: true
+`
+	if err := os.WriteFile(file, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rules, err = LoadReclaimRules(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The rules of the file come first and replace the built-in ones of the
+	// same name, disabled ones are dropped
+	var want []string
+	want = append(want, "venv", "npm-cache")
+	for _, name := range defaults {
+		if name != "npm-cache" && name != "node_modules" {
+			want = append(want, name)
+		}
+	}
+	if names := ruleNames(rules); !reflect.DeepEqual(names, want) {
+		t.Errorf("got rules %v, want %v", names, want)
+	}
+	if !reflect.DeepEqual(rules[1].Paths, []string{"/srv/npm"}) {
+		t.Errorf("npm-cache has paths %v, want the configured ones", rules[1].Paths)
+	}
+
+	for config, want := range map[string]string{
+		"rules:\n  - names: [x]\n":              "name is required",
+		"rules:\n  - name: x\n":                 "names or paths are required",
+		"rules:\n  - name: x\n    names: ['[']": "invalid pattern",
+		"rules:\n  - name: x\n    size: 1\n":    "field size not found",
+	} {
+		if err := os.WriteFile(file, []byte(config), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadReclaimRules(file); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("loading %q gave %v, want %q", config, err, want)
+		}
+	}
+}
+
+func TestRemoveOldFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"logs/old.log":     "old",
+		"logs/touched.log": "touched",
+		"logs/new.log":     "new",
+	})
+	old := time.Now().AddDate(0, 0, -40)
+	for _, name := range []string{"old.log", "touched.log"} {
+		if err := os.Chtimes(filepath.Join(dir, "logs", name), old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+	root := writeFiles(t, dir, nil)
+
+	rules := []ReclaimRule{{Name: "logs", Names: []string{"logs"}, OlderThanDays: 30}}
+	matches := FindReclaimable(root, rules)
+	if len(matches) != 1 || len(matches[0].Files) != 2 || matches[0].Size != int64(len("old")+len("touched")) {
+		t.Fatalf("found %+v, want the two old logs", matches)
+	}
+
+	// A file written to since the scan is kept
+	if err := os.WriteFile(filepath.Join(dir, "logs", "touched.log"), []byte("again"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cleaned, err := CleanReclaimable(rules, matches)
+	if err != nil || cleaned != 1 {
+		t.Fatalf("CleanReclaimable = %d, %v, want 1, nil", cleaned, err)
+	}
+	for name, kept := range map[string]bool{"old.log": false, "touched.log": true, "new.log": true} {
+		if _, err := os.Stat(filepath.Join(dir, "logs", name)); (err == nil) != kept {
+			t.Errorf("%s kept: %v, want %v", name, err == nil, kept)
+		}
+	}
+
+	// Files removed in the meantime are skipped
+	if err := removeOldFiles([]string{filepath.Join(dir, "logs", "old.log")}, time.Now()); err != nil {
+		t.Errorf("removing a missing file: %v", err)
+	}
+}
@@ -1,24 +1,33 @@
 package Utils
 
 import (
+	"io/fs"
 	"os"
 	"path/filepath"
-	"runtime"
 	"sort"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 type DirEntry struct {
-	Path     string
-	Name     string
-	Size     int64
-	Children []DirEntry
+	Path       string     `json:"path"`
+	Name       string     `json:"name"`
+	Size       int64      `json:"size"`
+	Allocated  int64      `json:"allocated,omitempty"` // Bytes taken up in storage, e.g. on disk or compressed in an archive
+	IsDir      bool       `json:"isDir,omitempty"`
+	UID        int        `json:"uid"`
+	GID        int        `json:"gid"`
+	ModTime    time.Time  `json:"modTime"`
+	AccessTime time.Time  `json:"accessTime"`
+	Incomplete bool       `json:"incomplete,omitempty"` // Some children could not be read or were left out, e.g. symlinks and excludes
+	Files      int64      `json:"files,omitempty"`      // Files below a directory whose children were dropped by ScanDirToDepth
+	Children   []DirEntry `json:"children,omitempty"`
 }
 
 // WorkItem represents a directory scan work item for the worker pool
 type WorkItem struct {
-	Path         string
+	Path         string // Name in the file system being scanned
 	MaxDepth     int
 	CurrentDepth int
 }
@@ -30,57 +39,128 @@ type ScanResult struct {
 	Error   error
 }
 
+// scanner scans the trees of one file system
+type scanner struct {
+	fsys          FileSystem
+	prefix        string // Path of the root of fsys
+	processedSize *int64
+	keepLevels    int // Directories this many levels down drop their children, 0 keeps them all
+}
+
 // ScanDir scans a directory tree with parallel processing for better performance
 func ScanDir(path string, maxDepth, currentDepth int, processedSize *int64) (DirEntry, int64, error) {
+	s := &scanner{fsys: NewOSFileSystem(path), prefix: path, processedSize: processedSize}
+	return s.scan(".", maxDepth, currentDepth)
+}
 
-	// For small depths, use concurrent scanning for better performance
-	if maxDepth == 0 || currentDepth < 2 {
-		return scanDirParallel(path, maxDepth, currentDepth, processedSize)
+// ScanDirToDepth scans the whole tree at path like ScanDir, but keeps the
+// children of directories only down to depth levels below path. Deeper
+// directories keep their size and count their files in Files instead.
+func ScanDirToDepth(path string, depth int, processedSize *int64) (DirEntry, int64, error) {
+	s := &scanner{fsys: NewOSFileSystem(path), prefix: path, processedSize: processedSize, keepLevels: depth + 1}
+	return s.scan(".", 0, 0)
+}
+
+// ScanFileSystem scans the whole tree of fsys. Entry paths are prefix joined
+// with the names in fsys.
+func ScanFileSystem(fsys FileSystem, prefix string, processedSize *int64) (DirEntry, int64, error) {
+	s := &scanner{fsys: fsys, prefix: prefix, processedSize: processedSize}
+	return s.scan(".", 0, 0)
+}
+
+// ScanFileSystemDir scans the tree at name in fsys, whose root is at prefix,
+// the way ScanDir scans a directory at currentDepth
+func ScanFileSystemDir(fsys FileSystem, prefix, name string, maxDepth, currentDepth int, processedSize *int64) (DirEntry, int64, error) {
+	s := &scanner{fsys: fsys, prefix: prefix, processedSize: processedSize}
+	return s.scan(name, maxDepth, currentDepth)
+}
+
+// entryPath returns the path of the entry for an io/fs name
+func (s *scanner) entryPath(name string) string {
+	if name == "." {
+		return s.prefix
 	}
+	return filepath.Join(s.prefix, filepath.FromSlash(name))
+}
 
-	return scanDirSequential(path, maxDepth, currentDepth, processedSize)
+// lstat returns the info of name and the bytes stored for it, if fsys knows
+func (s *scanner) lstat(name string) (fs.FileInfo, int64, error) {
+	if blocksFS, ok := s.fsys.(BlocksFileSystem); ok {
+		return blocksFS.LstatBlocks(name)
+	}
+	info, err := s.fsys.Lstat(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	return info, info.Size(), nil
 }
 
-// scanDirSequential performs a sequential directory scan (for deeper levels)
-func scanDirSequential(path string, maxDepth, currentDepth int, processedSize *int64) (DirEntry, int64, error) {
+// newEntry returns the entry for name with the details of info
+func (s *scanner) newEntry(name string, info fs.FileInfo) DirEntry {
 	entry := DirEntry{
-		Path: path,
-		Name: filepath.Base(path),
+		Path:  s.entryPath(name),
+		IsDir: info.IsDir(),
+	}
+	entry.Name = filepath.Base(entry.Path)
+	entry.UID, entry.GID = fileOwner(info)
+	entry.ModTime, entry.AccessTime = info.ModTime(), fileAccessTime(info)
+	return entry
+}
+
+func (s *scanner) scan(name string, maxDepth, currentDepth int) (DirEntry, int64, error) {
+	// For small depths, use concurrent scanning for better performance
+	if maxDepth == 0 || currentDepth < 2 {
+		return s.scanDirParallel(name, maxDepth, currentDepth)
 	}
 
-	info, err := os.Lstat(path)
+	return s.scanDirSequential(name, maxDepth, currentDepth)
+}
+
+// scanDirSequential performs a sequential directory scan (for deeper levels)
+func (s *scanner) scanDirSequential(name string, maxDepth, currentDepth int) (DirEntry, int64, error) {
+	info, allocated, err := s.lstat(name)
 	if err != nil {
-		return entry, 0, err
+		return DirEntry{Path: s.entryPath(name), Name: filepath.Base(s.entryPath(name))}, 0, err
 	}
+	entry := s.newEntry(name, info)
 	if !info.IsDir() {
 		entry.Size = info.Size()
-		atomic.AddInt64(processedSize, entry.Size)
+		entry.Allocated = allocated
+		atomic.AddInt64(s.processedSize, entry.Size)
 		return entry, 0, nil
 	}
 
-	entries, err := os.ReadDir(path)
+	entries, err := s.fsys.ReadDir(name)
 	if err != nil {
+		entry.Incomplete = true
 		return entry, info.Size(), nil
 	}
 
 	var totalSize, skipped int64
 	for _, e := range entries {
-		fullPath := filepath.Join(path, e.Name())
-		childInfo, err := os.Lstat(fullPath)
+		childName := joinName(name, e.Name())
+		if Excluded(s.entryPath(childName)) {
+			entry.Incomplete = true
+			continue
+		}
+		childInfo, err := s.fsys.Lstat(childName)
 		if err != nil || childInfo.Mode()&os.ModeSymlink != 0 {
 			if err != nil {
 				skipped += info.Size()
 			}
+			entry.Incomplete = true
 			continue
 		}
 
-		childEntry, skippedChild, err := ScanDir(fullPath, maxDepth, currentDepth+1, processedSize)
+		childEntry, skippedChild, err := s.scan(childName, maxDepth, currentDepth+1)
 		if err != nil {
 			skipped += childInfo.Size()
+			entry.Incomplete = true
 			continue
 		}
 		entry.Children = append(entry.Children, childEntry)
 		totalSize += childEntry.Size
+		entry.Allocated += childEntry.Allocated
 		skipped += skippedChild
 	}
 
@@ -90,40 +170,39 @@ func scanDirSequential(path string, maxDepth, currentDepth int, processedSize *i
 	})
 
 	entry.Size = totalSize
+	s.dropChildren(&entry, currentDepth)
 	// atomic.AddInt64(processedSize, entry.Size)
 	return entry, skipped, nil
 }
 
 // scanDirParallel performs a parallel directory scan using worker pools
-func scanDirParallel(path string, maxDepth, currentDepth int, processedSize *int64) (DirEntry, int64, error) {
-	entry := DirEntry{
-		Path: path,
-		Name: filepath.Base(path),
-	}
-
-	info, err := os.Lstat(path)
+func (s *scanner) scanDirParallel(name string, maxDepth, currentDepth int) (DirEntry, int64, error) {
+	info, allocated, err := s.lstat(name)
 	if err != nil {
-		return entry, 0, err
+		return DirEntry{Path: s.entryPath(name), Name: filepath.Base(s.entryPath(name))}, 0, err
 	}
+	entry := s.newEntry(name, info)
 	if !info.IsDir() {
 		entry.Size = info.Size()
-		atomic.AddInt64(processedSize, entry.Size)
+		entry.Allocated = allocated
+		atomic.AddInt64(s.processedSize, entry.Size)
 		return entry, 0, nil
 	}
 
-	entries, err := os.ReadDir(path)
+	entries, err := s.fsys.ReadDir(name)
 	if err != nil {
+		entry.Incomplete = true
 		return entry, info.Size(), nil
 	}
 
 	// For small directories, just process sequentially
 	if len(entries) < 5 {
-		return scanDirSequential(path, maxDepth, currentDepth, processedSize)
+		return s.scanDirSequential(name, maxDepth, currentDepth)
 	}
 
 	var wg sync.WaitGroup
 	resultChan := make(chan ScanResult, len(entries))
-	workerCount := runtime.NumCPU()
+	workerCount := scanWorkers()
 
 	// Create work queue
 	workQueue := make(chan WorkItem, len(entries))
@@ -132,7 +211,7 @@ func scanDirParallel(path string, maxDepth, currentDepth int, processedSize *int
 	for i := 0; i < workerCount; i++ {
 		go func() {
 			for work := range workQueue {
-				childEntry, childSkipped, childErr := ScanDir(work.Path, work.MaxDepth, work.CurrentDepth, processedSize)
+				childEntry, childSkipped, childErr := s.scan(work.Path, work.MaxDepth, work.CurrentDepth)
 				resultChan <- ScanResult{
 					Entry:   childEntry,
 					Skipped: childSkipped,
@@ -145,15 +224,20 @@ func scanDirParallel(path string, maxDepth, currentDepth int, processedSize *int
 
 	// Add work to the queue
 	for _, e := range entries {
-		fullPath := filepath.Join(path, e.Name())
-		childInfo, err := os.Lstat(fullPath)
+		childName := joinName(name, e.Name())
+		if Excluded(s.entryPath(childName)) {
+			entry.Incomplete = true
+			continue
+		}
+		childInfo, err := s.fsys.Lstat(childName)
 		if err != nil || childInfo.Mode()&os.ModeSymlink != 0 {
+			entry.Incomplete = true
 			continue
 		}
 
 		wg.Add(1)
 		workQueue <- WorkItem{
-			Path:         fullPath,
+			Path:         childName,
 			MaxDepth:     maxDepth,
 			CurrentDepth: currentDepth + 1,
 		}
@@ -175,10 +259,12 @@ func scanDirParallel(path string, maxDepth, currentDepth int, processedSize *int
 	for result := range resultChan {
 		if result.Error != nil {
 			skipped += 0 // Could add file size estimation here
+			entry.Incomplete = true
 			continue
 		}
 		children = append(children, result.Entry)
 		totalSize += result.Entry.Size
+		entry.Allocated += result.Entry.Allocated
 		skipped += result.Skipped
 	}
 
@@ -189,6 +275,23 @@ func scanDirParallel(path string, maxDepth, currentDepth int, processedSize *int
 
 	entry.Children = children
 	entry.Size = totalSize
+	s.dropChildren(&entry, currentDepth)
 	// atomic.AddInt64(processedSize, entry.Size)
 	return entry, skipped, nil
 }
+
+// dropChildren replaces the children of a directory below the levels the
+// scanner keeps by the number of files below it
+func (s *scanner) dropChildren(entry *DirEntry, currentDepth int) {
+	if s.keepLevels == 0 || currentDepth < s.keepLevels {
+		return
+	}
+	for _, child := range entry.Children {
+		if child.IsDir {
+			entry.Files += child.Files
+		} else {
+			entry.Files++
+		}
+	}
+	entry.Children = nil
+}
@@ -0,0 +1,229 @@
+package Utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// memTree returns an in-memory file system with the given files, by name
+// and size, each stored in twice its size
+func memTree(t *testing.T, files map[string]int64) *MemFileSystem {
+	t.Helper()
+	fsys := NewMemFileSystem()
+	for name, size := range files {
+		if err := fsys.AddFile(name, size, 2*size, time.Time{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return fsys
+}
+
+// childNames returns the names of the children of entry in order
+func childNames(entry DirEntry) []string {
+	var names []string
+	for _, child := range entry.Children {
+		names = append(names, child.Name)
+	}
+	return names
+}
+
+// findEntry returns the entry below root at the slash-separated name
+func findEntry(t *testing.T, root DirEntry, name string) DirEntry {
+	t.Helper()
+	var found *DirEntry
+	WalkEntries(root, func(e DirEntry, depth int) bool {
+		if e.Path == filepath.Join(root.Path, filepath.FromSlash(name)) {
+			found = &e
+		}
+		return found == nil
+	})
+	if found == nil {
+		t.Fatalf("%s is not in the scan", name)
+	}
+	return *found
+}
+
+func TestScanFileSystemSizes(t *testing.T) {
+	fsys := memTree(t, map[string]int64{
+		"a.txt":         100,
+		"docs/b.pdf":    300,
+		"docs/old/c.gz": 50,
+	})
+	var processed int64
+	root, skipped, err := ScanFileSystem(fsys, "/data", &processed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if root.Size != 450 || root.Allocated != 900 {
+		t.Errorf("root size %d allocated %d, want 450 and 900", root.Size, root.Allocated)
+	}
+	if processed != 450 || skipped != 0 {
+		t.Errorf("processed %d skipped %d, want 450 and 0", processed, skipped)
+	}
+	if docs := findEntry(t, root, "docs"); !docs.IsDir || docs.Size != 350 || docs.Allocated != 700 {
+		t.Errorf("docs = dir %v size %d allocated %d, want a directory of 350 and 700", docs.IsDir, docs.Size, docs.Allocated)
+	}
+	if c := findEntry(t, root, "docs/old/c.gz"); c.IsDir || c.Size != 50 || c.Name != "c.gz" {
+		t.Errorf("docs/old/c.gz = %+v, want a file of 50 bytes", c)
+	}
+}
+
+func TestScanFileSystemSkipsSymlinks(t *testing.T) {
+	fsys := memTree(t, map[string]int64{"a.txt": 10, "dir/b.txt": 20})
+	if err := fsys.AddSymlink("link", time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.AddSymlink("dir/link", time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var processed int64
+	root, _, err := ScanFileSystem(fsys, "/data", &processed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := childNames(root); !reflect.DeepEqual(names, []string{"dir", "a.txt"}) {
+		t.Errorf("root children = %v, want dir and a.txt without the symlink", names)
+	}
+	if names := childNames(findEntry(t, root, "dir")); !reflect.DeepEqual(names, []string{"b.txt"}) {
+		t.Errorf("dir children = %v, want b.txt without the symlink", names)
+	}
+}
+
+func TestScanFileSystemSortsBySize(t *testing.T) {
+	// More than 4 entries are scanned in parallel, fewer sequentially
+	for _, count := range []int{3, 8} {
+		files := make(map[string]int64)
+		for i := 0; i < count; i++ {
+			files[fmt.Sprintf("f%d", i)] = int64((i*7)%count+1) * 10
+		}
+
+		var processed int64
+		root, _, err := ScanFileSystem(memTree(t, files), "/data", &processed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(root.Children) != count {
+			t.Fatalf("%d files: scanned %d children", count, len(root.Children))
+		}
+		for i := 1; i < len(root.Children); i++ {
+			if root.Children[i-1].Size < root.Children[i].Size {
+				t.Errorf("%d files: children not sorted by size: %v", count, childNames(root))
+				break
+			}
+		}
+	}
+}
+
+func TestScanFileSystemExcluded(t *testing.T) {
+	ApplyConfig(Config{Exclude: []string{"*.tmp", "node_modules", filepath.FromSlash("/data/skip/me")}})
+	t.Cleanup(func() { ApplyConfig(DefaultConfig()) })
+
+	fsys := memTree(t, map[string]int64{
+		"keep.txt":            10,
+		"cache.tmp":           20,
+		"node_modules/x.js":   30,
+		"src/node_modules/y":  40,
+		"src/main.go":         50,
+		"skip/me/z.bin":       60,
+		"skip/other/kept.bin": 70,
+	})
+	var processed int64
+	root, _, err := ScanFileSystem(fsys, filepath.FromSlash("/data"), &processed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.Size != 130 {
+		t.Errorf("root size %d, want 130 without the excluded files", root.Size)
+	}
+	if names := childNames(root); !reflect.DeepEqual(names, []string{"skip", "src", "keep.txt"}) {
+		t.Errorf("root children = %v, want skip, src and keep.txt", names)
+	}
+	if names := childNames(findEntry(t, root, "skip")); !reflect.DeepEqual(names, []string{"other"}) {
+		t.Errorf("skip children = %v, want other", names)
+	}
+}
+
+func TestScanFileSystemDirSequential(t *testing.T) {
+	// A wide tree, so that every level would be scanned in parallel
+	files := make(map[string]int64)
+	size := int64(1)
+	for _, a := range []string{"a", "b", "c", "d", "e", "f"} {
+		for _, b := range []string{"a", "b", "c", "d", "e", "f"} {
+			for _, c := range []string{"1", "2", "3", "4", "5"} {
+				files[a+"/"+b+"/"+c] = size
+				size++
+			}
+		}
+	}
+	fsys := memTree(t, files)
+
+	var parallelProcessed, sequentialProcessed int64
+	parallel, _, err := ScanFileSystem(fsys, "/data", &parallelProcessed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// With a depth limit, levels from 2 down are scanned sequentially
+	sequential, _, err := ScanFileSystemDir(fsys, "/data", ".", 3, 0, &sequentialProcessed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(parallel, sequential) {
+		t.Error("parallel and sequential scans differ")
+	}
+	if parallelProcessed != sequentialProcessed || parallel.Size != parallelProcessed {
+		t.Errorf("processed %d and %d, size %d, want the same", parallelProcessed, sequentialProcessed, parallel.Size)
+	}
+	if want := size * (size - 1) / 2; parallel.Size != want {
+		t.Errorf("size %d, want %d", parallel.Size, want)
+	}
+}
+
+func TestScanDirToDepth(t *testing.T) {
+	dir := t.TempDir()
+	for name, size := range map[string]int{"a.txt": 10, "b/c.txt": 20, "b/d/e.txt": 30, "b/d/f/g.txt": 40, "b/d/f/h.txt": 50} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var processed int64
+	full, _, err := ScanDir(dir, 0, 0, &processed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for depth := 0; depth <= 4; depth++ {
+		limited, _, err := ScanDirToDepth(dir, depth, &processed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if limited.Size != 150 {
+			t.Errorf("depth %d: size %d, want 150", depth, limited.Size)
+		}
+		deepest := 0
+		WalkEntries(limited, func(e DirEntry, level int) bool {
+			deepest = max(deepest, level)
+			return true
+		})
+		if deepest > depth+1 {
+			t.Errorf("depth %d: kept entries %d levels down", depth, deepest)
+		}
+
+		var want, got []DirMetric
+		wantFiles := collectDirMetrics(full, 0, depth, &want)
+		gotFiles := collectDirMetrics(limited, 0, depth, &got)
+		if wantFiles != 5 || gotFiles != 5 || !reflect.DeepEqual(got, want) {
+			t.Errorf("depth %d: metrics %v with %d files, want %v with 5", depth, got, gotFiles, want)
+		}
+	}
+}
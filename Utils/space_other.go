@@ -0,0 +1,14 @@
+//go:build !windows
+
+package Utils
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// GetUsableSpace returns the available disk space
+func GetUsableSpace(path string) (uint64, error) {
+	// For non-Windows platforms, return an error
+	return 0, fmt.Errorf("GetUsableSpace not implemented for %s", runtime.GOOS)
+}
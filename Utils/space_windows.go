@@ -0,0 +1,40 @@
+//go:build windows
+
+package Utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/windows"
+)
+
+// GetUsableSpace returns the available disk space
+func GetUsableSpace(path string) (uint64, error) {
+	// Get the volume path (e.g., C:\)
+	volumePath := filepath.VolumeName(path)
+	if volumePath == "" {
+		// If path doesn't have a volume name, use the current directory
+		cwd, err := os.Getwd()
+		if err != nil {
+			return 0, err
+		}
+		volumePath = filepath.VolumeName(cwd)
+	}
+
+	// Ensure volume path ends with separator
+	if !strings.HasSuffix(volumePath, "\\") {
+		volumePath += "\\"
+	}
+
+	// Use Windows API via golang.org/x/sys/windows
+	var free, total, totalFree uint64
+	windows.GetDiskFreeSpaceEx(
+		windows.StringToUTF16Ptr(volumePath),
+		&free,
+		&total,
+		&totalFree)
+
+	return free, nil
+}
@@ -0,0 +1,93 @@
+package Utils
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os/exec"
+	"strings"
+)
+
+// SSHTarget is a directory on another machine, written as
+// ssh://[user@]host[:port]/path
+type SSHTarget struct {
+	Host string // [user@]host
+	Port string
+	Path string
+}
+
+// ParseSSHTarget parses an ssh:// URL. A path starting with /~ is taken as
+// relative to the remote home directory.
+func ParseSSHTarget(target string) (SSHTarget, error) {
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme != "ssh" || u.Hostname() == "" {
+		return SSHTarget{}, fmt.Errorf("invalid ssh target %q, expected ssh://[user@]host[:port]/path", target)
+	}
+
+	host := u.Hostname()
+	if u.User != nil {
+		host = u.User.Username() + "@" + host
+	}
+
+	path := u.Path
+	switch {
+	case path == "" || path == "/~":
+		path = "."
+	case strings.HasPrefix(path, "/~/"):
+		path = strings.TrimPrefix(path, "/~/")
+	}
+	return SSHTarget{Host: host, Port: u.Port(), Path: path}, nil
+}
+
+// String returns the target as an ssh:// URL
+func (t SSHTarget) String() string {
+	host := t.Host
+	if t.Port != "" {
+		host += ":" + t.Port
+	}
+	if strings.HasPrefix(t.Path, "/") {
+		return "ssh://" + host + t.Path
+	}
+	if t.Path == "." {
+		return "ssh://" + host + "/~"
+	}
+	return "ssh://" + host + "/~/" + t.Path
+}
+
+// ScanOverSSH runs `<command> scan <path> --format json` on the target
+// through the local ssh binary and reads the snapshot it streams back.
+// Messages of the remote side are copied to stderr.
+func ScanOverSSH(target SSHTarget, command string, stderr io.Writer) (Snapshot, error) {
+	var args []string
+	if target.Port != "" {
+		args = append(args, "-p", target.Port)
+	}
+	args = append(args, "--", target.Host, command, "scan", shellQuote(target.Path), "--format", "json")
+
+	cmd := exec.Command("ssh", args...)
+	cmd.Stderr = stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if err := cmd.Start(); err != nil {
+		return Snapshot{}, fmt.Errorf("could not run ssh: %v", err)
+	}
+
+	snapshot, readErr := ReadSnapshot(stdout)
+	// Drain the rest so ssh can exit
+	io.Copy(io.Discard, stdout)
+	if err := cmd.Wait(); err != nil {
+		return Snapshot{}, fmt.Errorf("remote scan of %s failed: %v", target, err)
+	}
+	if readErr != nil {
+		return Snapshot{}, readErr
+	}
+	return snapshot, nil
+}
+
+// shellQuote quotes an argument for the remote shell that ssh runs the
+// command in
+func shellQuote(arg string) string {
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
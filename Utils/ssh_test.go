@@ -0,0 +1,142 @@
+package Utils
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSSHTarget(t *testing.T) {
+	tests := []struct {
+		target string
+		want   SSHTarget
+		url    string
+	}{
+		{"ssh://server/var/log", SSHTarget{Host: "server", Path: "/var/log"}, "ssh://server/var/log"},
+		{"ssh://server/~", SSHTarget{Host: "server", Path: "."}, "ssh://server/~"},
+		{"ssh://server", SSHTarget{Host: "server", Path: "."}, "ssh://server/~"},
+		{"ssh://server/~/projects/x", SSHTarget{Host: "server", Path: "projects/x"}, "ssh://server/~/projects/x"},
+		{"ssh://alice@server:2222/srv", SSHTarget{Host: "alice@server", Port: "2222", Path: "/srv"}, "ssh://alice@server:2222/srv"},
+		{"ssh://bob@server/~/x", SSHTarget{Host: "bob@server", Path: "x"}, "ssh://bob@server/~/x"},
+	}
+	for _, test := range tests {
+		got, err := ParseSSHTarget(test.target)
+		if err != nil {
+			t.Errorf("ParseSSHTarget(%q): %v", test.target, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("ParseSSHTarget(%q) = %+v, want %+v", test.target, got, test.want)
+		}
+		if got.String() != test.url {
+			t.Errorf("ParseSSHTarget(%q).String() = %q, want %q", test.target, got.String(), test.url)
+		}
+	}
+
+	for _, target := range []string{"server:/path", "http://server/path", "ssh:///path"} {
+		if _, err := ParseSSHTarget(target); err == nil {
+			t.Errorf("ParseSSHTarget(%q) succeeded, want an error", target)
+		}
+	}
+}
+
+// fakeSSH puts an ssh command on PATH that runs the remote command locally
+// like sshd would, through sh, and a fake disksizer that prints the
+// snapshot in the file snapshot.json and records the path it was given. It
+// returns the directory of both.
+func fakeSSH(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake ssh is a shell script")
+	}
+	dir := t.TempDir()
+
+	ssh := `#!/bin/sh
+# Drop the options, the separator and the host
+while [ "$1" != "--" ]; do shift; done
+shift 2
+exec sh -c "$*"
+`
+	disksizer := `#!/bin/sh
+# disksizer scan <path> --format json
+printf '%s' "$2" > "` + dir + `/path"
+if [ "$2" = "fail" ]; then
+	echo "scan: permission denied" >&2
+	exit 3
+fi
+cat "` + dir + `/snapshot.json"
+`
+	for name, script := range map[string]string{"ssh": ssh, "fake-disksizer": disksizer} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return dir
+}
+
+func TestScanOverSSH(t *testing.T) {
+	dir := fakeSSH(t)
+
+	root := DirEntry{Path: "/data", Name: "data", IsDir: true, Size: 300, Children: []DirEntry{
+		{Path: "/data/a.bin", Name: "a.bin", Size: 300},
+	}}
+	snapshot := NewSnapshot(root, 7)
+	snapshot.ScannedAt = time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := SaveSnapshot(filepath.Join(dir, "snapshot.json"), snapshot); err != nil {
+		t.Fatal(err)
+	}
+
+	target := SSHTarget{Host: "user@server", Port: "2222", Path: "/data"}
+	var stderr bytes.Buffer
+	got, err := ScanOverSSH(target, "fake-disksizer", &stderr)
+	if err != nil {
+		t.Fatalf("ScanOverSSH: %v (stderr %q)", err, stderr.String())
+	}
+	if got.Path != "/data" || got.Skipped != 7 || got.Root.Size != 300 || len(got.Root.Children) != 1 {
+		t.Errorf("ScanOverSSH read %+v, want the snapshot of /data", got)
+	}
+	if !got.ScannedAt.Equal(snapshot.ScannedAt) {
+		t.Errorf("ScannedAt = %v, want %v", got.ScannedAt, snapshot.ScannedAt)
+	}
+}
+
+func TestScanOverSSHFailure(t *testing.T) {
+	fakeSSH(t)
+
+	var stderr bytes.Buffer
+	_, err := ScanOverSSH(SSHTarget{Host: "server", Path: "fail"}, "fake-disksizer", &stderr)
+	if err == nil {
+		t.Fatal("ScanOverSSH succeeded, want the exit status of the remote scan")
+	}
+	if !strings.Contains(err.Error(), "exit status 3") {
+		t.Errorf("ScanOverSSH error = %v, want the exit status", err)
+	}
+	if !strings.Contains(stderr.String(), "permission denied") {
+		t.Errorf("remote stderr = %q, want the message of the remote scan", stderr.String())
+	}
+}
+
+func TestScanOverSSHQuoting(t *testing.T) {
+	dir := fakeSSH(t)
+	if err := SaveSnapshot(filepath.Join(dir, "snapshot.json"), NewSnapshot(DirEntry{Path: "/x", IsDir: true}, 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"/srv/it's here", `/a "b" $HOME; rm -rf x`, "relative/dir"} {
+		if _, err := ScanOverSSH(SSHTarget{Host: "server", Path: path}, "fake-disksizer", &bytes.Buffer{}); err != nil {
+			t.Fatalf("ScanOverSSH(%q): %v", path, err)
+		}
+		received, err := os.ReadFile(filepath.Join(dir, "path"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(received) != path {
+			t.Errorf("remote command got path %q, want %q", received, path)
+		}
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 	"github.com/shirou/gopsutil/v3/disk"
 )
@@ -13,56 +12,65 @@ import (
 func fmtSize(size uint64) float64 {
 	return float64(size) / 1e9 // Convert to GB
 }
-func getUsageColor(percent float64) tcell.Color {
-	switch {
-	case percent <= 50:
-		// Green (0,255,0) to Yellow (255,255,0)
-		// Interpolate red from 0 to 255
-		r := int(255 * (percent / 50)) // 0 → 255
-		g := 255                       // constant
-		return tcell.NewRGBColor(int32(r), int32(g), 0)
-
-	case percent <= 80:
-		// Yellow (255,255,0) to Orange (255,165,0)
-		// Interpolate green from 255 → 165
-		g := int(255 - ((percent - 50) / 30 * 90)) // 255 → 165
-		return tcell.NewRGBColor(255, int32(g), 0)
-
-	default:
-		// Orange (255,165,0) to Red (255,0,0)
-		// Interpolate green from 165 → 0
-		g := int(165 * ((100 - percent) / 20)) // 165 → 0
-		return tcell.NewRGBColor(255, int32(g), 0)
+
+// PartitionUsage is the usage of one mounted partition
+type PartitionUsage struct {
+	Mountpoint  string  `json:"mountpoint"`
+	Total       uint64  `json:"total"`
+	Used        uint64  `json:"used"`
+	Free        uint64  `json:"free"`
+	UsedPercent float64 `json:"usedPercent"`
+}
+
+// PartitionUsages returns the usage of every partition that can be read
+func PartitionUsages() ([]PartitionUsage, error) {
+	partitions, err := disk.Partitions(true)
+	if err != nil {
+		return nil, err
 	}
+
+	var usages []PartitionUsage
+	for _, p := range partitions {
+		usage, err := disk.Usage(p.Mountpoint)
+		if err != nil {
+			continue
+		}
+		usages = append(usages, PartitionUsage{
+			Mountpoint:  p.Mountpoint,
+			Total:       usage.Total,
+			Used:        usage.Used,
+			Free:        usage.Free,
+			UsedPercent: usage.UsedPercent,
+		})
+	}
+	return usages, nil
 }
 
 // GetDiskStatsInteractive returns disk stats with clickable elements
 func GetDiskStatsInteractive(textView *tview.TextView, app *tview.Application) string {
+	usages, err := PartitionUsages()
+	return RenderDiskStats(textView, app, "📊 System Storage Status", usages, err)
+}
+
+// RenderDiskStats returns the usage of the given partitions with clickable
+// mountpoints, or err if they could not be read
+func RenderDiskStats(textView *tview.TextView, app *tview.Application, title string, usages []PartitionUsage, err error) string {
 	// Set up clickable handler
 	styling.InstallClickHandler(textView, app)
 
 	var result strings.Builder
 
-	result.WriteString(styling.CreateHeader("📊 System Storage Status"))
+	result.WriteString(styling.CreateHeader(title))
 	result.WriteString("")
 
-	partitions, err := disk.Partitions(true)
 	if err != nil {
-		errorStyle := styling.NewStyleBuilder().
-			WithTextColor(tcell.ColorRed).
-			Build()
-		result.WriteString(styling.ApplyStyle(fmt.Sprintf("Error fetching partitions: %v\n", err), errorStyle))
+		result.WriteString(styling.ApplyStyle(fmt.Sprintf("Error fetching partitions: %v\n", err), styling.Current.Error))
 		return result.String()
 	}
 
-	for _, p := range partitions {
-		usage, err := disk.Usage(p.Mountpoint)
-		if err != nil {
-			continue
-		}
-
+	for _, usage := range usages {
 		// Make mountpoint clickable to explore that location
-		mountpoint := p.Mountpoint
+		mountpoint := usage.Mountpoint
 		result.WriteString("\n" + styling.WrapWithAction(textView, mountpoint, func() {
 			// This will be called when the mountpoint is clicked
 			// You'll need to implement the actual directory navigation logic
@@ -76,10 +84,10 @@ func GetDiskStatsInteractive(textView *tview.TextView, app *tview.Application) s
 
 		progressBar := styling.CreateProgressBar(usedGB, totalGB, 40)
 		result.WriteString(progressBar + "\n")
-		usedColor := getUsageColor(usage.UsedPercent)
-		freeColor := getUsageColor(100 - usage.UsedPercent)
+		usedColor := styling.Current.UsageColor(usage.UsedPercent)
+		freeColor := styling.Current.UsageColor(100 - usage.UsedPercent)
 
-		totalInfo := styling.CreateInfoText("Total", fmt.Sprintf("%.2f GB", totalGB), tcell.ColorWhite)
+		totalInfo := styling.CreateInfoText("Total", fmt.Sprintf("%.2f GB", totalGB), styling.Current.Text)
 		usedInfo := styling.CreateInfoText("Used", fmt.Sprintf("%.2f GB (%.1f%%)", usedGB, usage.UsedPercent), usedColor)
 		freeInfo := styling.CreateInfoText("Free", fmt.Sprintf("%.2f GB (%.1f%%)", freeGB, 100-usage.UsedPercent), freeColor)
 
@@ -91,11 +99,19 @@ func GetDiskStatsInteractive(textView *tview.TextView, app *tview.Application) s
 	return result.String()
 }
 
-// Implement these functions to handle the actions
+// exploreHandler is called with a mountpoint when a partition is selected
+var exploreHandler func(path string)
+
+// SetExploreHandler registers the function that opens a selected partition
+func SetExploreHandler(handler func(path string)) {
+	exploreHandler = handler
+}
+
+// exploreDirectory is called when a disk partition is clicked or selected
+// and hands the mountpoint to the registered explore handler
 func exploreDirectory(path string, app *tview.Application) {
-	// This function is called when a disk partition is clicked
-	// It should update the app state to navigate to the selected directory
-	// You can implement the actual navigation logic here
-	// For example, you might want to call a function in the app package
-	// that updates the tree view to show the selected directory
+	if exploreHandler == nil {
+		return
+	}
+	exploreHandler(path)
 }
@@ -0,0 +1,99 @@
+package Utils
+
+import (
+	"math"
+	"sort"
+)
+
+// Rect is a rectangle in treemap layout space
+type Rect struct {
+	X, Y, W, H float64
+}
+
+// Squarify lays out values as a squarified treemap (Bruls, Huizing and van
+// Wijk) filling the rectangle at x, y with size w by h. The returned slice has
+// one rectangle per value in the original order; values <= 0 get an empty
+// rectangle.
+func Squarify(values []float64, x, y, w, h float64) []Rect {
+	rects := make([]Rect, len(values))
+
+	// Lay out the largest values first
+	var order []int
+	var total float64
+	for i, v := range values {
+		if v > 0 {
+			order = append(order, i)
+			total += v
+		}
+	}
+	if total <= 0 || w <= 0 || h <= 0 {
+		return rects
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return values[order[a]] > values[order[b]]
+	})
+
+	// Scale the values so they add up to the available area
+	scale := w * h / total
+	areas := make([]float64, len(order))
+	for i, idx := range order {
+		areas[i] = values[idx] * scale
+	}
+
+	for start := 0; start < len(areas); {
+		short := math.Min(w, h)
+
+		// Grow the row while that improves its worst aspect ratio
+		end := start + 1
+		for end < len(areas) && worstRatio(areas[start:end+1], short) <= worstRatio(areas[start:end], short) {
+			end++
+		}
+
+		var rowArea float64
+		for _, a := range areas[start:end] {
+			rowArea += a
+		}
+
+		if w >= h {
+			// Place the row as a column along the left edge
+			colW := rowArea / h
+			yy := y
+			for i := start; i < end; i++ {
+				rh := areas[i] / colW
+				rects[order[i]] = Rect{X: x, Y: yy, W: colW, H: rh}
+				yy += rh
+			}
+			x += colW
+			w -= colW
+		} else {
+			// Place the row along the top edge
+			rowH := rowArea / w
+			xx := x
+			for i := start; i < end; i++ {
+				rw := areas[i] / rowH
+				rects[order[i]] = Rect{X: xx, Y: y, W: rw, H: rowH}
+				xx += rw
+			}
+			y += rowH
+			h -= rowH
+		}
+
+		start = end
+	}
+
+	return rects
+}
+
+// worstRatio returns the largest aspect ratio of a row of areas laid out
+// along a side of the given length
+func worstRatio(row []float64, side float64) float64 {
+	var sum float64
+	minArea, maxArea := math.Inf(1), 0.0
+	for _, a := range row {
+		sum += a
+		minArea = math.Min(minArea, a)
+		maxArea = math.Max(maxArea, a)
+	}
+	side2, sum2 := side*side, sum*sum
+	return math.Max(side2*maxArea/sum2, sum2/(side2*minArea))
+}
@@ -0,0 +1,32 @@
+package Utils
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// NoExtension is the key used for files without an extension
+const NoExtension = "(none)"
+
+// TypeBreakdown sums the size and number of files below entry per
+// extension and per category
+func TypeBreakdown(entry DirEntry) (byExtension, byCategory []UsageStat) {
+	extensions := make(usageCounter)
+	categories := make(usageCounter)
+
+	WalkEntries(entry, func(e DirEntry, depth int) bool {
+		if e.IsDir {
+			return true
+		}
+
+		ext := strings.ToLower(filepath.Ext(e.Name))
+		if ext == "" {
+			ext = NoExtension
+		}
+		extensions.add(ext, e.Size)
+		categories.add(GetFileCategory(e.Name), e.Size)
+		return true
+	})
+
+	return extensions.list(), categories.list()
+}
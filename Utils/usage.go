@@ -0,0 +1,60 @@
+package Utils
+
+import "sort"
+
+// UsageStat is the disk usage of one group of files, such as an extension
+// or an owner
+type UsageStat struct {
+	Key   string
+	Size  int64
+	Count int
+}
+
+// Sort orders for breakdown reports
+const (
+	SortBySize  = "size"
+	SortByCount = "count"
+	SortByName  = "name"
+)
+
+// usageCounter sums file sizes and counts per key
+type usageCounter map[string]*UsageStat
+
+func (c usageCounter) add(key string, size int64) {
+	stat, ok := c[key]
+	if !ok {
+		stat = &UsageStat{Key: key}
+		c[key] = stat
+	}
+	stat.Size += size
+	stat.Count++
+}
+
+// list returns the collected stats sorted by size
+func (c usageCounter) list() []UsageStat {
+	list := make([]UsageStat, 0, len(c))
+	for _, stat := range c {
+		list = append(list, *stat)
+	}
+	SortUsageStats(list, SortBySize)
+	return list
+}
+
+// SortUsageStats sorts stats by size or count (largest first) or by name
+func SortUsageStats(stats []UsageStat, by string) {
+	sort.Slice(stats, func(i, j int) bool {
+		switch by {
+		case SortByCount:
+			if stats[i].Count != stats[j].Count {
+				return stats[i].Count > stats[j].Count
+			}
+		case SortByName:
+			return stats[i].Key < stats[j].Key
+		default:
+			if stats[i].Size != stats[j].Size {
+				return stats[i].Size > stats[j].Size
+			}
+		}
+		return stats[i].Key < stats[j].Key
+	})
+}
@@ -0,0 +1,64 @@
+package Utils
+
+// WalkEntries calls fn for entry and all of its descendants in depth-first
+// order. Returning false from fn skips the children of that entry.
+func WalkEntries(entry DirEntry, fn func(e DirEntry, depth int) bool) {
+	walkEntries(entry, 0, fn)
+}
+
+func walkEntries(entry DirEntry, depth int, fn func(e DirEntry, depth int) bool) {
+	if !fn(entry, depth) {
+		return
+	}
+	for _, child := range entry.Children {
+		walkEntries(child, depth+1, fn)
+	}
+}
+
+// FilterEntries returns a copy of entry that only contains the files for
+// which keep returns true and the directories leading to them. Directory
+// sizes are recomputed from the files that are kept.
+func FilterEntries(entry DirEntry, keep func(e DirEntry) bool) DirEntry {
+	filtered, _ := filterEntries(entry, keep)
+	return filtered
+}
+
+func filterEntries(entry DirEntry, keep func(e DirEntry) bool) (DirEntry, bool) {
+	if !entry.IsDir {
+		return entry, keep(entry)
+	}
+
+	filtered := entry
+	filtered.Children = nil
+	filtered.Size = 0
+	for _, child := range entry.Children {
+		if filteredChild, ok := filterEntries(child, keep); ok {
+			filtered.Children = append(filtered.Children, filteredChild)
+			filtered.Size += filteredChild.Size
+		}
+	}
+	return filtered, len(filtered.Children) > 0
+}
+
+// FilterMatches returns a copy of entry that only contains the entries below
+// it for which match returns true, with all of their contents, and the
+// directories leading to them. Directory sizes are recomputed from the
+// entries that are kept.
+func FilterMatches(entry DirEntry, match func(e DirEntry) bool) DirEntry {
+	filtered := entry
+	filtered.Children = nil
+	filtered.Size = 0
+	for _, child := range entry.Children {
+		if !match(child) {
+			if !child.IsDir {
+				continue
+			}
+			if child = FilterMatches(child, match); len(child.Children) == 0 {
+				continue
+			}
+		}
+		filtered.Children = append(filtered.Children, child)
+		filtered.Size += child.Size
+	}
+	return filtered
+}
@@ -0,0 +1,128 @@
+package agent
+
+import (
+	"DiskSizer/Utils"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+)
+
+// Client talks to a remote agent
+type Client struct {
+	Address string
+	token   string
+	baseURL string
+	http    *http.Client
+}
+
+// NewClient creates a client for the agent at address, which is host:port,
+// http://host:port or unix:/path/to/socket
+func NewClient(address, token string) *Client {
+	c := &Client{Address: address, token: token, http: &http.Client{}}
+
+	if socket, isUnix := strings.CutPrefix(address, "unix:"); isUnix {
+		c.baseURL = "http://agent"
+		c.http.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socket)
+			},
+		}
+	} else if strings.Contains(address, "://") {
+		c.baseURL = strings.TrimSuffix(address, "/")
+	} else {
+		c.baseURL = "http://" + address
+	}
+	return c
+}
+
+// Info returns the host name, root and partitions of the agent
+func (c *Client) Info() (Info, error) {
+	var info Info
+	err := c.call(http.MethodGet, "/v1/info", nil, nil, &info)
+	return info, err
+}
+
+// Stat returns the entry at path without its children
+func (c *Client) Stat(path string) (Utils.DirEntry, error) {
+	var entry Utils.DirEntry
+	err := c.call(http.MethodGet, "/v1/stat", url.Values{"path": {path}}, nil, &entry)
+	return entry, err
+}
+
+// Children returns the entry at path with its direct children
+func (c *Client) Children(path string) (Utils.DirEntry, error) {
+	var entry Utils.DirEntry
+	err := c.call(http.MethodGet, "/v1/children", url.Values{"path": {path}}, nil, &entry)
+	return entry, err
+}
+
+// ScanDir scans the whole tree below path on the agent. processedSize is
+// updated once the scan is done.
+func (c *Client) ScanDir(path string, processedSize *int64) (Utils.DirEntry, int64, error) {
+	var response ScanResponse
+	if err := c.call(http.MethodPost, "/v1/scan", nil, pathRequest{Path: path}, &response); err != nil {
+		return Utils.DirEntry{Path: path}, 0, err
+	}
+	atomic.AddInt64(processedSize, response.Entry.Size)
+	return response.Entry, response.Skipped, nil
+}
+
+// Delete removes a file or directory tree on the agent
+func (c *Client) Delete(path string) error {
+	return c.call(http.MethodPost, "/v1/delete", nil, pathRequest{Path: path}, nil)
+}
+
+// call sends a request to the agent and decodes the JSON response into out
+func (c *Client) call(method, endpoint string, query url.Values, body any, out any) error {
+	target := c.baseURL + endpoint
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	request, err := http.NewRequest(method, target, reader)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", "Bearer "+c.token)
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := c.http.Do(request)
+	if err != nil {
+		return fmt.Errorf("agent %s: %v", c.Address, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 300 {
+		var agentError struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(response.Body).Decode(&agentError) == nil && agentError.Error != "" {
+			return fmt.Errorf("agent %s: %s", c.Address, agentError.Error)
+		}
+		return fmt.Errorf("agent %s: %s", c.Address, response.Status)
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(response.Body).Decode(out)
+}
@@ -0,0 +1,291 @@
+package agent
+
+import (
+	cache "DiskSizer/Cache"
+	"DiskSizer/Utils"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Info describes the machine an agent runs on
+type Info struct {
+	Hostname   string                 `json:"hostname"`
+	OS         string                 `json:"os"`
+	Root       string                 `json:"root"`
+	Partitions []Utils.PartitionUsage `json:"partitions"`
+}
+
+// ScanResponse is the result of a scan request
+type ScanResponse struct {
+	Entry   Utils.DirEntry `json:"entry"`
+	Skipped int64          `json:"skipped"`
+}
+
+// pathRequest is the body of the requests that act on a path
+type pathRequest struct {
+	Path string `json:"path"`
+}
+
+// Server exposes scan, list-children and, if allowed, delete operations on
+// the directories below its root over HTTP and JSON. Every request must
+// carry the token as a bearer token.
+type Server struct {
+	root        string
+	token       string
+	allowDelete bool
+	cache       *cache.DirSizeCache
+}
+
+// NewServer creates an agent for the directories below root. Deleting is
+// refused unless allowDelete is set.
+func NewServer(root, token string, allowDelete bool) *Server {
+	return &Server{
+		root:        root,
+		token:       token,
+		allowDelete: allowDelete,
+		cache:       cache.NewDirSizeCache(),
+	}
+}
+
+// Listen opens the address an agent serves on, either host:port or
+// unix:/path/to/socket. Unix sockets are only accessible to their owner.
+func Listen(address string) (net.Listener, error) {
+	socket, isUnix := strings.CutPrefix(address, "unix:")
+	if !isUnix {
+		return net.Listen("tcp", address)
+	}
+
+	// Remove a socket left behind by an agent that did not shut down cleanly
+	if info, err := os.Lstat(socket); err == nil && info.Mode()&os.ModeSocket != 0 {
+		os.Remove(socket)
+	}
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(socket, 0o600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// Handler returns the HTTP handler of the agent API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/info", s.handleInfo)
+	mux.HandleFunc("GET /v1/stat", s.handleStat)
+	mux.HandleFunc("GET /v1/children", s.handleChildren)
+	mux.HandleFunc("POST /v1/scan", s.handleScan)
+	mux.HandleFunc("POST /v1/delete", s.handleDelete)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			writeError(w, http.StatusUnauthorized, "invalid token")
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleInfo(w http.ResponseWriter, r *http.Request) {
+	hostname, _ := os.Hostname()
+	partitions, _ := Utils.PartitionUsages()
+
+	// Only report the partition holding the root and the ones below it
+	var visible []Utils.PartitionUsage
+	var holding *Utils.PartitionUsage
+	for i, p := range partitions {
+		switch {
+		case s.allowed(p.Mountpoint):
+			visible = append(visible, p)
+		case isWithin(s.root, p.Mountpoint) && (holding == nil || len(p.Mountpoint) > len(holding.Mountpoint)):
+			holding = &partitions[i]
+		}
+	}
+	if holding != nil {
+		visible = append([]Utils.PartitionUsage{*holding}, visible...)
+	}
+
+	writeJSON(w, http.StatusOK, Info{
+		Hostname:   hostname,
+		OS:         runtime.GOOS,
+		Root:       s.root,
+		Partitions: visible,
+	})
+}
+
+// handleStat returns the entry at ?path= without its children
+func (s *Server) handleStat(w http.ResponseWriter, r *http.Request) {
+	path, ok := s.checkPath(w, r.URL.Query().Get("path"))
+	if !ok {
+		return
+	}
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	entry := Utils.DirEntry{
+		Path:    path,
+		Name:    filepath.Base(path),
+		IsDir:   info.IsDir(),
+		ModTime: info.ModTime(),
+	}
+	if !info.IsDir() {
+		entry.Size = info.Size()
+	}
+	writeJSON(w, http.StatusOK, entry)
+}
+
+// handleChildren returns the entry at ?path= with its direct children,
+// scanning it unless an earlier scan covered it
+func (s *Server) handleChildren(w http.ResponseWriter, r *http.Request) {
+	path, ok := s.checkPath(w, r.URL.Query().Get("path"))
+	if !ok {
+		return
+	}
+
+	cachedEntry, found := s.cache.Lookup(path)
+	if !found {
+		var processedSize int64
+		if _, _, err := cache.CachedScanDir(path, 0, 0, &processedSize, s.cache); err != nil {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		cachedEntry, _ = s.cache.Get(path)
+	}
+
+	entry := cache.ToUtilsDirEntry(cachedEntry)
+	for i := range entry.Children {
+		entry.Children[i].Children = nil
+	}
+	writeJSON(w, http.StatusOK, entry)
+}
+
+// handleScan scans the whole tree below a path and returns it
+func (s *Server) handleScan(w http.ResponseWriter, r *http.Request) {
+	path, ok := s.decodePath(w, r)
+	if !ok {
+		return
+	}
+
+	var processedSize int64
+	entry, skipped, err := Utils.ScanDir(path, 0, 0, &processedSize)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	s.cache.Invalidate(path)
+	s.cache.Set(path, cache.FromUtilsDirEntry(entry))
+
+	writeJSON(w, http.StatusOK, ScanResponse{Entry: entry, Skipped: skipped})
+}
+
+// handleDelete removes a file or directory tree below the root
+func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request) {
+	if !s.allowDelete {
+		writeError(w, http.StatusForbidden, "deleting is disabled, start the agent with --allow-delete")
+		return
+	}
+	path, ok := s.decodePath(w, r)
+	if !ok {
+		return
+	}
+	if s.isRoot(path) {
+		writeError(w, http.StatusForbidden, "refusing to delete the agent root")
+		return
+	}
+
+	if _, err := os.Lstat(path); err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err := os.RemoveAll(path); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.cache.Invalidate(path)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodePath reads and checks the path of a JSON request body
+func (s *Server) decodePath(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var request pathRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+		return "", false
+	}
+	return s.checkPath(w, request.Path)
+}
+
+// checkPath makes sure a requested path is absolute and below the root
+func (s *Server) checkPath(w http.ResponseWriter, path string) (string, bool) {
+	if !filepath.IsAbs(path) {
+		writeError(w, http.StatusBadRequest, "path must be absolute")
+		return "", false
+	}
+	path = filepath.Clean(path)
+	if !s.allowed(path) {
+		writeError(w, http.StatusForbidden, "path is outside of "+s.root)
+		return "", false
+	}
+
+	// Don't follow symlinks out of the root. Paths that cannot be resolved
+	// are refused, as where they lead is not known.
+	root, err := filepath.EvalSymlinks(s.root)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return "", false
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return "", false
+	}
+	if err != nil || !isWithin(resolved, root) {
+		writeError(w, http.StatusForbidden, "path is outside of "+s.root)
+		return "", false
+	}
+	return path, true
+}
+
+// isRoot reports whether path is the root, or a symlink to it
+func (s *Server) isRoot(path string) bool {
+	resolved, err := filepath.EvalSymlinks(path)
+	root, rootErr := filepath.EvalSymlinks(s.root)
+	return path == s.root || (err == nil && rootErr == nil && resolved == root)
+}
+
+// allowed reports whether path is the root or lies below it
+func (s *Server) allowed(path string) bool {
+	return isWithin(path, s.root)
+}
+
+// isWithin reports whether path is dir or lies below it
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,198 @@
+package agent
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testToken = "secret"
+
+// startAgent serves an agent for root on a loopback port and returns its
+// address
+func startAgent(t *testing.T, root string, allowDelete bool) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: NewServer(root, testToken, allowDelete).Handler()}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+	return listener.Addr().String()
+}
+
+// request sends a raw request to the agent and returns the status code
+func request(t *testing.T, address, token, method, endpoint, body string) int {
+	t.Helper()
+	request, err := http.NewRequest(method, "http://"+address+endpoint, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	return response.StatusCode
+}
+
+// makeTree creates files with the given sizes below dir
+func makeTree(t *testing.T, dir string, files map[string]int) {
+	t.Helper()
+	for name, size := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestToken(t *testing.T) {
+	root := t.TempDir()
+	address := startAgent(t, root, false)
+
+	for _, token := range []string{"", "wrong", testToken + "x"} {
+		if status := request(t, address, token, http.MethodGet, "/v1/info", ""); status != http.StatusUnauthorized {
+			t.Errorf("token %q: got status %d, want %d", token, status, http.StatusUnauthorized)
+		}
+	}
+	if status := request(t, address, testToken, http.MethodGet, "/v1/info", ""); status != http.StatusOK {
+		t.Errorf("valid token: got status %d, want %d", status, http.StatusOK)
+	}
+}
+
+func TestStatChildrenScan(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, map[string]int{"a.txt": 100, "sub/b.bin": 300, "sub/deep/c.bin": 50})
+	client := NewClient(startAgent(t, root, false), testToken)
+
+	info, err := client.Info()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Root != root {
+		t.Errorf("Info root = %q, want %q", info.Root, root)
+	}
+
+	stat, err := client.Stat(filepath.Join(root, "a.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stat.IsDir || stat.Size != 100 {
+		t.Errorf("Stat a.txt = dir %v size %d, want a file of 100 bytes", stat.IsDir, stat.Size)
+	}
+
+	children, err := client.Children(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sizes := make(map[string]int64)
+	for _, child := range children.Children {
+		if len(child.Children) != 0 {
+			t.Errorf("Children returned the children of %s", child.Name)
+		}
+		sizes[child.Name] = child.Size
+	}
+	if sizes["a.txt"] != 100 || sizes["sub"] != 350 || len(sizes) != 2 {
+		t.Errorf("Children sizes = %v, want a.txt 100 and sub 350", sizes)
+	}
+
+	var processed int64
+	entry, _, err := client.ScanDir(filepath.Join(root, "sub"), &processed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Size != 350 || processed != 350 {
+		t.Errorf("ScanDir size = %d, processed %d, want 350", entry.Size, processed)
+	}
+}
+
+func TestPathsOutsideRoot(t *testing.T) {
+	outside := t.TempDir()
+	makeTree(t, outside, map[string]int{"secret.txt": 10})
+	root := t.TempDir()
+	makeTree(t, root, map[string]int{"inside.txt": 10})
+	if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+		t.Skip("symlinks are not supported:", err)
+	}
+	address := startAgent(t, root, true)
+
+	forbidden := []string{
+		outside,
+		filepath.Join(root, ".."),
+		filepath.Join(root, "..", filepath.Base(outside), "secret.txt"),
+		filepath.Join(root, "escape"),
+		filepath.Join(root, "escape", "secret.txt"),
+	}
+	for _, path := range forbidden {
+		query := "?" + url.Values{"path": {path}}.Encode()
+		for _, endpoint := range []string{"/v1/stat", "/v1/children"} {
+			if status := request(t, address, testToken, http.MethodGet, endpoint+query, ""); status != http.StatusForbidden {
+				t.Errorf("%s %s: got status %d, want %d", endpoint, path, status, http.StatusForbidden)
+			}
+		}
+		body, _ := json.Marshal(pathRequest{Path: path})
+		for _, endpoint := range []string{"/v1/scan", "/v1/delete"} {
+			if status := request(t, address, testToken, http.MethodPost, endpoint, string(body)); status != http.StatusForbidden {
+				t.Errorf("%s %s: got status %d, want %d", endpoint, path, status, http.StatusForbidden)
+			}
+		}
+	}
+	if _, err := os.Stat(filepath.Join(outside, "secret.txt")); err != nil {
+		t.Errorf("file outside of the root is gone: %v", err)
+	}
+
+	if status := request(t, address, testToken, http.MethodGet, "/v1/stat?path=inside.txt", ""); status != http.StatusBadRequest {
+		t.Errorf("relative path: got status %d, want %d", status, http.StatusBadRequest)
+	}
+	missing := "?" + url.Values{"path": {filepath.Join(root, "missing")}}.Encode()
+	if status := request(t, address, testToken, http.MethodGet, "/v1/stat"+missing, ""); status != http.StatusNotFound {
+		t.Errorf("missing path: got status %d, want %d", status, http.StatusNotFound)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, map[string]int{"keep.txt": 10, "old/file.bin": 10})
+	target := filepath.Join(root, "old")
+
+	client := NewClient(startAgent(t, root, false), testToken)
+	if err := client.Delete(target); err == nil {
+		t.Error("Delete succeeded on an agent without --allow-delete")
+	}
+	if _, err := os.Stat(target); err != nil {
+		t.Fatalf("Delete without --allow-delete removed %s", target)
+	}
+
+	client = NewClient(startAgent(t, root, true), testToken)
+	if err := client.Delete(root); err == nil {
+		t.Error("Delete of the root succeeded")
+	}
+	if err := os.Symlink(root, filepath.Join(root, "self")); err == nil {
+		if err := client.Delete(filepath.Join(root, "self", ".")); err == nil {
+			t.Error("Delete of a symlink to the root succeeded")
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "keep.txt")); err != nil {
+		t.Fatalf("root was deleted: %v", err)
+	}
+
+	if err := client.Delete(target); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Errorf("%s still exists after Delete", target)
+	}
+}
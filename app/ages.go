@@ -0,0 +1,105 @@
+package app
+
+import (
+	"DiskSizer/Utils"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rivo/tview"
+)
+
+// Age pane state
+var (
+	agesTable        *tview.Table
+	agesEntry        Utils.DirEntry
+	agesByAccessTime = false
+)
+
+// showAgesPane shows the age histogram of the current directory
+func showAgesPane() {
+	entry, found := currentDirEntry()
+	if !found {
+		return
+	}
+	agesEntry = entry
+
+	if agesTable == nil {
+		agesTable = newUsageTable()
+
+		// Enter limits the tree to the files in the selected age range
+		agesTable.SetSelectedFunc(func(row, column int) {
+			ref := agesTable.GetCell(row, 0).GetReference()
+			if ref == nil {
+				return
+			}
+			filterByAgeBucket(ref.(string))
+			closePane()
+		})
+
+		paneHandlers["ages"] = map[string]func(){
+			actionSwitchView: func() {
+				agesByAccessTime = !agesByAccessTime
+				renderAgesTable()
+			},
+			actionStaleDays: promptStaleDays,
+		}
+	}
+
+	renderAgesTable()
+	showPane("ages", agesTable)
+}
+
+// ageKind names the timestamp the age pane is based on
+func ageKind() string {
+	if agesByAccessTime {
+		return "accessed"
+	}
+	return "modified"
+}
+
+// renderAgesTable fills the age table for agesEntry
+func renderAgesTable() {
+	stats := Utils.AgeBreakdown(agesEntry, time.Now(), agesByAccessTime)
+
+	agesTable.SetTitle(fmt.Sprintf(" Age of files in %s by last %s "+theme.Tag(theme.Muted)+"(%s) ",
+		tview.Escape(agesEntry.Path), ageKind(), tview.Escape(keyHints(
+			"ENTER: Show Only This Range",
+			keyHint(actionStaleDays, "Not Modified in N Days"),
+			keyHint(actionSwitchView, "Modified/Accessed"),
+			keyHint(actionAges, "Close")))))
+	renderUsageTable(agesTable, "Last "+ageKind(), stats, agesEntry.Size, nil)
+}
+
+// filterByAgeBucket limits the tree to the files of one age range
+func filterByAgeBucket(label string) {
+	now := time.Now()
+	byAccessTime := agesByAccessTime
+	setTreeFilter(fmt.Sprintf("files %s %s ago", ageKind(), label), func(e Utils.DirEntry) bool {
+		return Utils.AgeBucketOf(Utils.FileAge(e, now, byAccessTime)) == label
+	})
+}
+
+// promptStaleDays asks for a number of days and limits the tree to files
+// that have not been modified (or accessed) in that time
+func promptStaleDays() {
+	showPrompt(fmt.Sprintf("Show files not %s in how many days: ", ageKind()), "90", nil, func(text string, ok bool) {
+		if !ok {
+			return
+		}
+
+		days, err := strconv.Atoi(strings.TrimSpace(text))
+		if err != nil || days < 0 {
+			statsView.SetText(theme.Tag(theme.Error) + "Please enter a number of days")
+			return
+		}
+
+		now := time.Now()
+		byAccessTime := agesByAccessTime
+		setTreeFilter(fmt.Sprintf("files not %s in %d days", ageKind(), days), func(e Utils.DirEntry) bool {
+			return Utils.IsStale(e, now, days, byAccessTime)
+		})
+		closePane()
+	})
+}
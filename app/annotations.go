@@ -0,0 +1,24 @@
+package app
+
+import (
+	"DiskSizer/Utils"
+)
+
+// annotator returns the annotation of the entry at path shown after its tree
+// label, or ""
+type annotator func(entry Utils.DirEntry, path string) string
+
+// annotators annotate the tree nodes outside of archives, in this order
+var annotators = []annotator{
+	reclaimBadge,
+	gitBadge,
+}
+
+// annotations returns the annotations of the entry at path
+func annotations(entry Utils.DirEntry, path string) string {
+	var result string
+	for _, annotate := range annotators {
+		result += annotate(entry, path)
+	}
+	return result
+}
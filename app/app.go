@@ -2,9 +2,9 @@ package app
 
 import (
 	cache "DiskSizer/Cache"
+	"DiskSizer/Utils"
 	"DiskSizer/styling"
 	"os"
-	"path/filepath"
 	"sync"
 
 	"github.com/gdamore/tcell/v2"
@@ -14,12 +14,15 @@ import (
 // Application global variables
 var (
 	// UI components
-	app        *tview.Application
-	flex       *tview.Flex
-	treeView   *tview.TreeView
-	statsView  *tview.TextView
-	headerView *tview.TextView
-	footerView *tview.TextView
+	app            *tview.Application
+	flex           *tview.Flex
+	treeView       *tview.TreeView
+	statsView      *tview.TextView
+	headerView     *tview.TextView
+	breadcrumbView *tview.TextView
+	footerView     *tview.TextView
+	mainPages      *tview.Pages
+	treemap        *treemapView
 
 	// State tracking - exported for use in other files
 	CurrentPath   string // Exported for use in navigation.go
@@ -35,10 +38,15 @@ var (
 
 func StartApp(startPath string) {
 	app = tview.NewApplication()
+	setScreen()
 	dirCache = cache.NewDirSizeCache()
 	scanCancel = make(chan bool, 1)
 
-	// If no start path provided, use current directory
+	// If no start path provided, use current directory (or the root of the
+	// agent or snapshot)
+	if startPath == "" {
+		startPath = defaultRoot
+	}
 	if startPath == "" {
 		var err error
 		startPath, err = os.Getwd()
@@ -49,11 +57,7 @@ func StartApp(startPath string) {
 	CurrentPath = startPath
 
 	// Create styled header
-	headerStyle := styling.NewStyleBuilder().
-		WithBold().
-		WithTextColor(tcell.ColorBlue).
-		Build()
-	headerText := styling.ApplyStyle("Welcome to DiskSizer, your tool to manage and organize your storage", headerStyle)
+	headerText := styling.ApplyStyle("Welcome to DiskSizer, your tool to manage and organize your storage", theme.Header)
 
 	headerView = tview.NewTextView().
 		SetText(headerText).
@@ -74,19 +78,34 @@ func StartApp(startPath string) {
 
 	// Install handler for clickable elements
 	styling.InstallClickHandler(statsView, app)
+	Utils.SetExploreHandler(explorePartition)
+
+	// Move between partitions with the arrow keys while the panel is focused
+	statsView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyUp, tcell.KeyLeft:
+			styling.SelectRegion(statsView, -1)
+			return nil
+		case tcell.KeyDown, tcell.KeyRight:
+			styling.SelectRegion(statsView, 1)
+			return nil
+		case tcell.KeyEscape:
+			togglePartitionFocus()
+			return nil
+		}
+		return event
+	})
 
 	// Update stats with interactive elements
 	updateStats()
 
+	// Create breadcrumb bar showing the tree root
+	breadcrumbView = tview.NewTextView().
+		SetDynamicColors(true)
+
 	// Create tree view
-	rootDir := filepath.Base(startPath)
-	root := tview.NewTreeNode(rootDir).
-		SetReference(startPath).
-		SetSelectable(true).
-		SetColor(tcell.ColorGreen)
-	treeView = tview.NewTreeView().
-		SetRoot(root).
-		SetCurrentNode(root)
+	treeView = tview.NewTreeView()
+	root := setTreeRoot(startPath)
 
 	// Add children for the root node
 	addChildren(root)
@@ -99,12 +118,12 @@ func StartApp(startPath string) {
 		}
 
 		path := reference.(string)
-		info, err := os.Stat(path)
+		info, err := fsys.Stat(path)
 		if err != nil {
 			return
 		}
 
-		if info.IsDir() {
+		if info.IsDir {
 			// If it's collapsed, expand it
 			if node.IsExpanded() {
 				node.Collapse()
@@ -122,54 +141,111 @@ func StartApp(startPath string) {
 		}
 	})
 
-	// Create styled footer with additional key mappings
-	footerStyle := styling.NewStyleBuilder().
-		WithTextColor(tcell.ColorGray).
-		Build()
-	footerText := styling.ApplyStyle("ENTER: Open/Collapse | BACKSPACE: Back | Q: Quit | SPACE: Refresh | C: Clear Cache", footerStyle)
+	// Create styled footer listing the keys of the keymap
+	footerText := styling.ApplyStyle(tview.Escape(footerText("")), theme.Footer)
 
 	footerView = tview.NewTextView().
 		SetText(footerText).
 		SetTextAlign(tview.AlignCenter).
 		SetDynamicColors(true)
 
+	// The tree and the alternative views share the main area
+	treemap = newTreemapView()
+	mainPages = tview.NewPages().
+		AddPage("tree", treeView, true, true).
+		AddPage("treemap", treemap, true, false)
+
 	// Create layout without separate progress view
 	flex = tview.NewFlex().
 		SetDirection(tview.FlexRow).
 		AddItem(headerView, 1, 0, false).
+		AddItem(breadcrumbView, 1, 0, false).
 		AddItem(statsView, 7, 0, false).
-		AddItem(treeView, 0, 1, true).
+		AddItem(mainPages, 0, 1, true).
 		AddItem(footerView, 1, 0, false)
 
 	// Handle key events
 	app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		switch event.Key() {
-		case tcell.KeyBackspace, tcell.KeyBackspace2:
+		// Let prompts and other widgets receive their own keys
+		if _, typing := app.GetFocus().(*tview.InputField); typing {
+			return event
+		}
+
+		// Analysis panes have keys of their own
+		if paneShown() {
+			return handlePaneKey(event)
+		}
+
+		action, consumed := keyAction(event)
+		switch action {
+		case actionOpen, actionUp, actionDown, actionCollapse, actionExpand,
+			actionTop, actionBottom, actionPageUp, actionPageDown:
+			return moveInTree(action, event)
+		case actionBack:
 			navigateUp()
-			return nil
-		case tcell.KeyRune:
-			switch event.Rune() {
-			case 'q', 'Q':
-				app.Stop()
-				return nil
-			case ' ':
-				updateStats()
-				refreshCurrentDir()
-				return nil
-			case 'c', 'C':
-				clearCache()
-				return nil
-			case 'e', 'E':
-				// Quick estimate mode
-				estimateCurrentDir()
-				return nil
-			case 's', 'S':
-				// Stop current scan if running
-				cancelScan()
+		case actionPartitions:
+			togglePartitionFocus()
+		case actionDelete:
+			deleteSelection()
+		case actionQuit:
+			app.Stop()
+		case actionRefresh:
+			updateStats()
+			refreshCurrentDir()
+		case actionClearCache:
+			clearCache()
+		case actionEstimate:
+			// Quick estimate mode
+			estimateCurrentDir()
+		case actionStopScan:
+			// Stop current scan if running
+			cancelScan()
+		case actionSearch:
+			startSearch()
+		case actionNextMatch:
+			jumpToHit(searchIndex + 1)
+		case actionPreviousMatch:
+			jumpToHit(searchIndex - 1)
+		case actionGoToPath:
+			startGoToPath()
+		case actionSetRoot:
+			rerootAtSelection()
+		case actionRootUp:
+			rerootUp()
+		case actionTreemap:
+			toggleTreemap()
+		case actionSizeBars:
+			toggleSizeBars()
+		case actionFileTypes:
+			showTypesPane()
+		case actionOwners:
+			showOwnersPane()
+		case actionAges:
+			showAgesPane()
+		case actionDuplicates:
+			showDupesPane()
+		case actionEmpty:
+			showEmptyPane()
+		case actionSaveSnapshot:
+			promptSaveSnapshot()
+		case actionCompare:
+			promptCompareSnapshot()
+		case actionReclaimable:
+			showReclaimPane()
+		case actionGitRepo:
+			showRepoPane()
+		case actionClearFilter:
+			clearSearch()
+			clearTreeFilter()
+		case actionHelp:
+			showHelpPane()
+		default:
+			if consumed {
 				return nil
 			}
+			return event
 		}
-		return event
+		return nil
 	})
 
 	// Start the application
@@ -177,3 +253,20 @@ func StartApp(startPath string) {
 		panic(err)
 	}
 }
+
+// setScreen gives the application its screen, drawn without colours for the
+// monochrome theme and on terminals that have none. Views read the theme when
+// they are created, so it runs before any is.
+func setScreen() {
+	screen, err := tcell.NewScreen()
+	if err != nil {
+		return // Run reports the error
+	}
+	if screen.Colors() < 8 && !theme.Monochrome {
+		styling.SetTheme("mono")
+	}
+	if theme.Monochrome {
+		screen = styling.NewMonochromeScreen(screen)
+	}
+	app.SetScreen(screen)
+}
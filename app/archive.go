@@ -0,0 +1,111 @@
+package app
+
+import (
+	"DiskSizer/Utils"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Indexes of the archives opened in the tree, by path
+var (
+	archives      = make(map[string]*Utils.MemFileSystem)
+	archivesMutex sync.Mutex
+)
+
+// splitArchivePath splits a path at the archive file it lies in. name is the
+// io/fs name within the archive, "." for the archive itself.
+func splitArchivePath(path string) (archive, name string, ok bool) {
+	for p := path; ; p = filepath.Dir(p) {
+		if Utils.IsArchive(p) {
+			if info, err := os.Stat(p); err == nil && info.Mode().IsRegular() {
+				rel, err := filepath.Rel(p, path)
+				if err != nil {
+					return "", "", false
+				}
+				return p, filepath.ToSlash(rel), true
+			}
+		}
+		if filepath.Dir(p) == p {
+			return "", "", false
+		}
+	}
+}
+
+// inArchive reports whether path is an archive or lies in one on the local
+// disks
+func inArchive(path string) bool {
+	if !isLocal() {
+		return false
+	}
+	_, _, ok := splitArchivePath(path)
+	return ok
+}
+
+// openArchive returns the index of an archive, reading it the first time
+func openArchive(file string) (*Utils.MemFileSystem, error) {
+	archivesMutex.Lock()
+	defer archivesMutex.Unlock()
+
+	if index, found := archives[file]; found {
+		return index, nil
+	}
+	index, err := Utils.OpenArchive(file)
+	if err != nil {
+		return nil, err
+	}
+	archives[file] = index
+	return index, nil
+}
+
+// closeArchives forgets the indexes read so far
+func closeArchives() {
+	archivesMutex.Lock()
+	defer archivesMutex.Unlock()
+	clear(archives)
+}
+
+// scanArchive scans the directory name within an archive like a directory
+// on disk, with the compressed sizes as Allocated
+func scanArchive(path, archive, name string, processedSize *int64) (Utils.DirEntry, int64, error) {
+	index, err := openArchive(archive)
+	if err != nil {
+		return Utils.DirEntry{Path: path}, 0, err
+	}
+	dir, err := index.Sub(name)
+	if err != nil {
+		return Utils.DirEntry{Path: path}, 0, err
+	}
+
+	entry, skipped, err := Utils.ScanFileSystem(dir, path, processedSize)
+	entry.Name = filepath.Base(path)
+	return entry, skipped, err
+}
+
+// statArchive returns the entry for name within an archive
+func statArchive(path, archive, name string) (Utils.DirEntry, error) {
+	if name == "." {
+		info, err := os.Stat(archive)
+		if err != nil {
+			return Utils.DirEntry{}, err
+		}
+		return Utils.DirEntry{Path: path, Name: filepath.Base(path), IsDir: true, Size: info.Size(), ModTime: info.ModTime()}, nil
+	}
+
+	index, err := openArchive(archive)
+	if err != nil {
+		return Utils.DirEntry{}, err
+	}
+	info, stored, err := index.LstatBlocks(name)
+	if err != nil {
+		return Utils.DirEntry{}, err
+	}
+	return Utils.DirEntry{Path: path, Name: info.Name(), IsDir: info.IsDir(), Size: info.Size(), Allocated: stored, ModTime: info.ModTime()}, nil
+}
+
+// compressedLabel shows the size an entry of an archive takes up in it, i.e.
+// its compressed size, after its label
+func compressedLabel(entry Utils.DirEntry) string {
+	return fmt.Sprintf(" "+theme.Tag(theme.Muted)+"%s in archive[-:-:-]", Utils.FormatSize(entry.Allocated))
+}
@@ -0,0 +1,79 @@
+package app
+
+import (
+	"DiskSizer/Utils"
+	"DiskSizer/styling"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// treeSort is the order of the tree, one of the Utils.SortTreeBy constants
+var treeSort = Utils.SortTreeBySize
+
+// theme styles the user interface
+var theme = &styling.Current
+
+// ApplyConfig applies the tree order, theme, colours and keymap of the
+// config. Call it before StartApp.
+func ApplyConfig(config Utils.Config) error {
+	if err := styling.SetTheme(config.Theme); err != nil {
+		return err
+	}
+	for element, name := range config.Colors {
+		style, known := theme.Element(element)
+		if !known {
+			return fmt.Errorf("colors: unknown element %q", element)
+		}
+		color, err := parseColor(name)
+		if err != nil {
+			return fmt.Errorf("colors: %s: %v", element, err)
+		}
+		switch {
+		case theme.Monochrome:
+		case style.BackgroundColor != tcell.ColorDefault:
+			style.BackgroundColor = color
+		default:
+			style.TextColor = color
+		}
+	}
+	if _, found := keymaps[config.Keymap]; !found {
+		return fmt.Errorf("unknown keymap %q, must be default or vim", config.Keymap)
+	}
+	if err := setKeys(config.Keymap, config.Keys); err != nil {
+		return fmt.Errorf("keys: %v", err)
+	}
+	treeSort = config.Sort
+	return nil
+}
+
+// parseColor returns the colour of a tcell colour name or #rrggbb
+func parseColor(name string) (tcell.Color, error) {
+	name = strings.ToLower(name)
+	if name == "default" {
+		return tcell.ColorDefault, nil
+	}
+	color := tcell.GetColor(name)
+	if color == tcell.ColorDefault {
+		return color, fmt.Errorf("unknown colour %q", name)
+	}
+	return color, nil
+}
+
+// sortedEntries returns the children of a directory in the order of the
+// tree, leaving the scan result as it is
+func sortedEntries(entries []Utils.DirEntry) []Utils.DirEntry {
+	children := append([]Utils.DirEntry(nil), entries...)
+	sort.SliceStable(children, func(i, j int) bool {
+		switch treeSort {
+		case Utils.SortTreeByName:
+			return strings.ToLower(children[i].Name) < strings.ToLower(children[j].Name)
+		case Utils.SortTreeByModified:
+			return children[i].ModTime.After(children[j].ModTime)
+		}
+		return children[i].Size > children[j].Size
+	})
+	return children
+}
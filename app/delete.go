@@ -0,0 +1,44 @@
+package app
+
+import (
+	"DiskSizer/agent"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+// deleteSelection asks for confirmation and deletes the selected file or
+// directory tree through the agent. Local files are not deleted this way.
+func deleteSelection() {
+	if _, remote := fsys.(*agent.Client); !remote {
+		statsView.SetText(theme.Tag(theme.Warning) + "Deleting is only available when browsing an agent")
+		return
+	}
+	node := treeView.GetCurrentNode()
+	if node == nil || node.GetReference() == nil || node == treeView.GetRoot() {
+		return
+	}
+	path := node.GetReference().(string)
+
+	question := fmt.Sprintf("Delete %s and everything in it? (y/n) ", tview.Escape(path))
+	showPrompt(question, "", nil, func(text string, ok bool) {
+		if !ok || strings.ToLower(strings.TrimSpace(text)) != "y" {
+			return
+		}
+
+		if err := fsys.Delete(path); err != nil {
+			statsView.SetText(fmt.Sprintf(theme.Tag(theme.Error)+"Error deleting %s: %v", tview.Escape(path), err))
+			return
+		}
+		dirCache.Invalidate(path)
+
+		if parent := findNodeByPath(treeView.GetRoot(), filepath.Dir(path)); parent != nil {
+			parent.RemoveChild(node)
+			treeView.SetCurrentNode(parent)
+			CurrentPath = filepath.Dir(path)
+		}
+		statsView.SetText(fmt.Sprintf(theme.Tag(theme.Success)+"Deleted %s. Press SPACE to rescan.", tview.Escape(path)))
+	})
+}
@@ -0,0 +1,142 @@
+package app
+
+import (
+	cache "DiskSizer/Cache"
+	"DiskSizer/Utils"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// diffTable lists the changes since a saved snapshot
+var diffTable *tview.Table
+
+// promptCompareSnapshot asks for a snapshot file and compares it against a
+// fresh scan of the same path
+func promptCompareSnapshot() {
+	showPrompt("Compare with snapshot: ", "", nil, func(text string, ok bool) {
+		if ok && strings.TrimSpace(text) != "" {
+			showDiffPane(strings.TrimSpace(text))
+		}
+	})
+}
+
+// promptSaveSnapshot asks for a file name and saves the scan of the tree
+// root as a snapshot that can be compared later
+func promptSaveSnapshot() {
+	rootPath := treeView.GetRoot().GetReference().(string)
+	name := fmt.Sprintf("disksizer-%s-%s.json", filepath.Base(rootPath), time.Now().Format("20060102-150405"))
+
+	showPrompt("Save snapshot to: ", name, nil, func(text string, ok bool) {
+		if !ok || strings.TrimSpace(text) == "" {
+			return
+		}
+
+		cachedEntry, found := dirCache.Lookup(rootPath)
+		if !found {
+			statsView.SetText(theme.Tag(theme.Warning) + "Wait for the scan to finish before saving a snapshot")
+			return
+		}
+
+		snapshot := Utils.NewSnapshot(cache.ToUtilsDirEntry(cachedEntry), 0)
+		if err := Utils.SaveSnapshot(strings.TrimSpace(text), snapshot); err != nil {
+			statsView.SetText(fmt.Sprintf(theme.Tag(theme.Error)+"Error saving snapshot: %v", err))
+			return
+		}
+		statsView.SetText(fmt.Sprintf(theme.Tag(theme.Success)+"Snapshot of %s saved to %s", rootPath, text))
+	})
+}
+
+// showDiffPane loads a snapshot and lists what changed since it was taken
+func showDiffPane(snapshotFile string) {
+	snapshot, err := Utils.LoadSnapshot(snapshotFile)
+	if err != nil {
+		statsView.SetText(fmt.Sprintf(theme.Tag(theme.Error)+"Error loading snapshot: %v", err))
+		return
+	}
+
+	if diffTable == nil {
+		diffTable = tview.NewTable().
+			SetFixed(1, 0).
+			SetSelectable(true, false)
+		diffTable.SetBorder(true)
+
+		// Enter reveals the selected path in the tree
+		diffTable.SetSelectedFunc(func(row, column int) {
+			ref := diffTable.GetCell(row, 0).GetReference()
+			if ref == nil {
+				return
+			}
+			if _, err := fsys.Stat(ref.(string)); err == nil {
+				CurrentPath = ref.(string)
+			}
+			closePane()
+		})
+	}
+
+	diffTable.Clear()
+	diffTable.SetTitle(fmt.Sprintf(" Changes in %s since %s ", tview.Escape(snapshot.Path), snapshot.ScannedAt.Format("2006-01-02 15:04")))
+	diffTable.SetCell(0, 0, tview.NewTableCell(theme.Tag(theme.Warning)+"Scanning...").SetSelectable(false))
+	showPane("diff", diffTable)
+
+	go func() {
+		// Files may have changed since the tree was scanned, so scan again
+		dirCache.Invalidate(snapshot.Path)
+		fresh, _, err := cachedScan(snapshot.Path)
+
+		app.QueueUpdateDraw(func() {
+			if err != nil {
+				diffTable.SetCell(0, 0, tview.NewTableCell(fmt.Sprintf(theme.Tag(theme.Error)+"Error scanning %s: %v", snapshot.Path, err)))
+				return
+			}
+			renderDiffTable(snapshot, fresh)
+		})
+	}()
+}
+
+// renderDiffTable lists the changes between a snapshot and a fresh scan
+func renderDiffTable(snapshot Utils.Snapshot, fresh Utils.DirEntry) {
+	diffs := Utils.DiffTrees(snapshot.Root, fresh)
+
+	diffTable.Clear()
+	diffTable.SetTitle(fmt.Sprintf(" Changes in %s since %s: %s -> %s (%s) "+theme.Tag(theme.Muted)+"(%s) ",
+		tview.Escape(snapshot.Path), snapshot.ScannedAt.Format("2006-01-02 15:04"),
+		Utils.FormatSize(snapshot.Root.Size), Utils.FormatSize(fresh.Size), Utils.FormatDelta(fresh.Size-snapshot.Root.Size),
+		tview.Escape(keyHints("ENTER: Show in Tree", keyHint(actionCompare, "Close")))))
+
+	headers := []string{"Change", "Delta", "Old", "New", "Path"}
+	for col, title := range headers {
+		diffTable.SetCell(0, col, tview.NewTableCell(title).
+			SetTextColor(theme.Heading.TextColor).
+			SetAttributes(tcell.AttrBold).
+			SetSelectable(false))
+	}
+
+	for i, d := range diffs {
+		row := i + 1
+		color := theme.Error.TextColor
+		if d.Delta() < 0 {
+			color = theme.Success.TextColor
+		}
+
+		path := d.Path
+		if d.IsDir {
+			path += string(filepath.Separator)
+		}
+
+		diffTable.SetCell(row, 0, tview.NewTableCell(d.Kind).SetReference(d.Path))
+		diffTable.SetCell(row, 1, tview.NewTableCell(Utils.FormatDelta(d.Delta())).SetTextColor(color).SetAlign(tview.AlignRight))
+		diffTable.SetCell(row, 2, tview.NewTableCell(Utils.FormatSize(d.OldSize)).SetAlign(tview.AlignRight))
+		diffTable.SetCell(row, 3, tview.NewTableCell(Utils.FormatSize(d.NewSize)).SetAlign(tview.AlignRight))
+		diffTable.SetCell(row, 4, tview.NewTableCell(tview.Escape(path)))
+	}
+
+	if len(diffs) == 0 {
+		diffTable.SetCell(1, 0, tview.NewTableCell(theme.Tag(theme.Success)+"Nothing changed").SetSelectable(false))
+	}
+	diffTable.Select(1, 0).ScrollToBeginning()
+}
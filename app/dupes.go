@@ -0,0 +1,159 @@
+package app
+
+import (
+	"DiskSizer/Utils"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+// Duplicate pane state
+var (
+	dupesTable  *tview.Table
+	dupesEntry  Utils.DirEntry
+	dupesGroups []Utils.DuplicateGroup
+)
+
+// dupeRef identifies a file of a duplicate group in the table
+type dupeRef struct {
+	group int
+	path  string
+}
+
+// showDupesPane searches the current directory for duplicate files
+func showDupesPane() {
+	if !localOnly("Finding duplicates") {
+		return
+	}
+	entry, found := currentDirEntry()
+	if !found {
+		return
+	}
+	dupesEntry = entry
+	dupesGroups = nil
+
+	if dupesTable == nil {
+		dupesTable = tview.NewTable().
+			SetSelectable(true, false)
+		dupesTable.SetBorder(true)
+
+		paneHandlers["dupes"] = map[string]func(){
+			actionCleanUp:  func() { resolveSelectedDuplicate(false) },
+			actionHardLink: func() { resolveSelectedDuplicate(true) },
+		}
+	}
+
+	dupesTable.Clear()
+	dupesTable.SetTitle(fmt.Sprintf(" Duplicates in %s ", tview.Escape(entry.Path)))
+	dupesTable.SetCell(0, 0, tview.NewTableCell(theme.Tag(theme.Warning)+"Comparing files...").SetSelectable(false))
+	showPane("dupes", dupesTable)
+
+	go func() {
+		groups := Utils.FindDuplicates(entry, 1, 0)
+		app.QueueUpdateDraw(func() {
+			if dupesEntry.Path != entry.Path {
+				return
+			}
+			dupesGroups = groups
+			renderDupesTable()
+		})
+	}()
+}
+
+// renderDupesTable lists every duplicate group followed by its files
+func renderDupesTable() {
+	var wasted int64
+	for _, group := range dupesGroups {
+		wasted += group.Wasted()
+	}
+
+	dupesTable.Clear()
+	dupesTable.SetTitle(fmt.Sprintf(" Duplicates in %s: %d groups, %s wasted "+theme.Tag(theme.Muted)+"(%s) ",
+		tview.Escape(dupesEntry.Path), len(dupesGroups), Utils.FormatSize(wasted), tview.Escape(keyHints(
+			keyHint(actionCleanUp, "Delete Others"),
+			keyHint(actionHardLink, "Hard-Link Others"),
+			keyHint(actionDuplicates, "Close")))))
+
+	if len(dupesGroups) == 0 {
+		dupesTable.SetCell(0, 0, tview.NewTableCell(theme.Tag(theme.Success)+"No duplicate files found").SetSelectable(false))
+		return
+	}
+
+	row := 0
+	for i, group := range dupesGroups {
+		header := fmt.Sprintf(theme.Tag(theme.Heading)+"%d copies of %s "+theme.Tag(theme.Muted)+"(%s wasted)",
+			len(group.Paths), Utils.FormatSize(group.Size), Utils.FormatSize(group.Wasted()))
+		dupesTable.SetCell(row, 0, tview.NewTableCell(header).SetSelectable(false))
+		row++
+
+		for _, path := range group.Paths {
+			dupesTable.SetCell(row, 0, tview.NewTableCell("  "+tview.Escape(path)).
+				SetReference(dupeRef{group: i, path: path}))
+			row++
+		}
+	}
+
+	dupesTable.Select(1, 0).ScrollToBeginning()
+}
+
+// resolveSelectedDuplicate keeps the selected file and, after confirmation,
+// deletes the other copies or replaces them with hard links
+func resolveSelectedDuplicate(hardLink bool) {
+	row, _ := dupesTable.GetSelection()
+	ref, ok := dupesTable.GetCell(row, 0).GetReference().(dupeRef)
+	if !ok {
+		return
+	}
+	group := dupesGroups[ref.group]
+
+	action := "Delete"
+	if hardLink {
+		action = "Hard-link"
+	}
+	question := fmt.Sprintf("%s %d other copies and keep %s? (y/n) ", action, len(group.Paths)-1, tview.Escape(ref.path))
+
+	showPrompt(question, "", nil, func(text string, ok bool) {
+		if !ok || strings.ToLower(strings.TrimSpace(text)) != "y" {
+			return
+		}
+
+		var changed, skipped []string
+		var err error
+		if hardLink {
+			changed, skipped, err = Utils.HardLinkDuplicates(group, ref.path)
+		} else {
+			changed, skipped, err = Utils.DeleteDuplicates(group, ref.path)
+		}
+
+		for _, path := range changed {
+			dirCache.Invalidate(path)
+		}
+
+		if err != nil {
+			statsView.SetText(fmt.Sprintf(theme.Tag(theme.Error)+"%s failed after %d files: %v", action, len(changed), err))
+		} else {
+			kept := ""
+			if len(skipped) > 0 {
+				kept = fmt.Sprintf(" %d files were kept as they no longer match.", len(skipped))
+			}
+			statsView.SetText(fmt.Sprintf(theme.Tag(theme.Success)+"%s done for %d files, %s reclaimed.%s Press SPACE in the tree to rescan.",
+				action, len(changed), Utils.FormatSize(group.Size*int64(len(changed))), kept))
+		}
+
+		// Drop the group once no duplicates are left
+		remaining := group.Paths[:0:0]
+		for _, path := range group.Paths {
+			if !slices.Contains(changed, path) && !slices.Contains(skipped, path) {
+				remaining = append(remaining, path)
+			}
+		}
+		if len(remaining) > 1 {
+			dupesGroups[ref.group].Paths = remaining
+		} else {
+			dupesGroups = append(dupesGroups[:ref.group], dupesGroups[ref.group+1:]...)
+		}
+		renderDupesTable()
+	})
+}
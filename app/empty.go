@@ -0,0 +1,94 @@
+package app
+
+import (
+	"DiskSizer/Utils"
+	"fmt"
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+// Empty entry pane state
+var (
+	emptyTable  *tview.Table
+	emptyEntry  Utils.DirEntry
+	emptyReport Utils.EmptyReport
+)
+
+// showEmptyPane lists empty directories and zero-byte files below the
+// current directory
+func showEmptyPane() {
+	if !localOnly("Cleaning up empty files") {
+		return
+	}
+	entry, found := currentDirEntry()
+	if !found {
+		return
+	}
+	emptyEntry = entry
+	emptyReport = Utils.FindEmpty(entry)
+
+	if emptyTable == nil {
+		emptyTable = tview.NewTable().
+			SetSelectable(true, false)
+		emptyTable.SetBorder(true)
+
+		paneHandlers["empty"] = map[string]func(){
+			actionCleanUp: cleanupEmpty,
+		}
+	}
+
+	renderEmptyTable()
+	showPane("empty", emptyTable)
+}
+
+// renderEmptyTable lists the empty directories followed by the empty files
+func renderEmptyTable() {
+	emptyTable.Clear()
+	emptyTable.SetTitle(fmt.Sprintf(" Empty entries in %s: %d directories, %d zero-byte files "+theme.Tag(theme.Muted)+"(%s) ",
+		tview.Escape(emptyEntry.Path), emptyReport.DirCount, len(emptyReport.Files), tview.Escape(keyHints(
+			keyHint(actionCleanUp, "Delete All"),
+			keyHint(actionEmpty, "Close")))))
+
+	row := 0
+	addSection := func(title string, paths []string) {
+		emptyTable.SetCell(row, 0, tview.NewTableCell(title).SetSelectable(false))
+		row++
+		for _, path := range paths {
+			emptyTable.SetCell(row, 0, tview.NewTableCell("  "+tview.Escape(path)))
+			row++
+		}
+	}
+
+	addSection(fmt.Sprintf(theme.Tag(theme.Heading)+"Empty directories (%d, %d listed)", emptyReport.DirCount, len(emptyReport.Dirs)), emptyReport.Dirs)
+	addSection(fmt.Sprintf(theme.Tag(theme.Heading)+"Zero-byte files (%d)", len(emptyReport.Files)), emptyReport.Files)
+
+	emptyTable.Select(1, 0).ScrollToBeginning()
+}
+
+// cleanupEmpty deletes everything in the report after confirmation
+func cleanupEmpty() {
+	if len(emptyReport.Dirs) == 0 && len(emptyReport.Files) == 0 {
+		return
+	}
+
+	question := fmt.Sprintf("Delete %d empty directories and %d zero-byte files? (y/n) ",
+		emptyReport.DirCount, len(emptyReport.Files))
+	showPrompt(question, "", nil, func(text string, ok bool) {
+		if !ok || strings.ToLower(strings.TrimSpace(text)) != "y" {
+			return
+		}
+
+		dirs, files, err := Utils.RemoveEmpty(emptyReport)
+		dirCache.Invalidate(emptyEntry.Path)
+
+		if err != nil {
+			statsView.SetText(fmt.Sprintf(theme.Tag(theme.Error)+"Removed %d directories and %d files before an error: %v", dirs, files, err))
+		} else {
+			statsView.SetText(fmt.Sprintf(theme.Tag(theme.Success)+"Removed %d directories and %d files. Press SPACE in the tree to rescan.", dirs, files))
+		}
+
+		emptyReport = Utils.EmptyReport{}
+		renderEmptyTable()
+	})
+}
@@ -0,0 +1,120 @@
+package app
+
+import (
+	cache "DiskSizer/Cache"
+	"DiskSizer/Utils"
+	"DiskSizer/agent"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// fileSystem is where the tree gets its data from: the local disks or a
+// remote agent
+type fileSystem interface {
+	ScanDir(path string, processedSize *int64) (Utils.DirEntry, int64, error)
+	Stat(path string) (Utils.DirEntry, error)
+	Delete(path string) error
+}
+
+// fsys is the file system shown in the tree
+var fsys fileSystem = localFileSystem{}
+
+// agentInfo describes the remote agent, it is nil for the local disks
+var agentInfo *agent.Info
+
+// agentInfoTTL is how long the partitions of the agent are shown before they
+// are fetched again
+const agentInfoTTL = 30 * time.Second
+
+// State of fetching agentInfo, only used on the UI goroutine
+var (
+	agentInfoFetched  time.Time
+	agentInfoErr      error
+	agentInfoFetching bool
+)
+
+// defaultRoot is where the tree starts when no path is given, empty for the
+// current directory
+var defaultRoot string
+
+// localFileSystem reads the disks of this machine. Archives on them can be
+// opened like directories.
+type localFileSystem struct{}
+
+func (localFileSystem) ScanDir(path string, processedSize *int64) (Utils.DirEntry, int64, error) {
+	if archive, name, ok := splitArchivePath(path); ok {
+		return scanArchive(path, archive, name, processedSize)
+	}
+	return Utils.ScanDir(path, 1, 0, processedSize)
+}
+
+func (localFileSystem) Stat(path string) (Utils.DirEntry, error) {
+	if archive, name, ok := splitArchivePath(path); ok {
+		return statArchive(path, archive, name)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return Utils.DirEntry{}, err
+	}
+	return Utils.DirEntry{Path: path, Name: filepath.Base(path), IsDir: info.IsDir(), Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+func (localFileSystem) Delete(path string) error {
+	return fmt.Errorf("deleting is only available when browsing an agent")
+}
+
+// UseAgent makes the tree browse the file system of a remote agent instead
+// of the local disks. Call it before StartApp.
+func UseAgent(client *agent.Client) error {
+	info, err := client.Info()
+	if err != nil {
+		return err
+	}
+	fsys = client
+	agentInfo = &info
+	agentInfoFetched = time.Now()
+	defaultRoot = info.Root
+	return nil
+}
+
+// cachedScan returns the cached scan of path, or scans it
+func cachedScan(path string) (Utils.DirEntry, int64, error) {
+	return cache.CachedScan(path, &processedSize, dirCache, fsys.ScanDir)
+}
+
+// isLocal reports whether the tree shows the local disks
+func isLocal() bool {
+	_, local := fsys.(localFileSystem)
+	return local
+}
+
+// localOnly reports whether a feature can be used, which is not the case for
+// features that read file contents when browsing an agent or a snapshot
+func localOnly(feature string) bool {
+	if !isLocal() {
+		statsView.SetText(fmt.Sprintf(theme.Tag(theme.Warning)+"%s is only available for local disks", feature))
+		return false
+	}
+	return true
+}
+
+// agentStats shows the partitions of the remote agent as last fetched, and
+// fetches them again in the background once they are older than agentInfoTTL
+func agentStats(client *agent.Client) string {
+	if !agentInfoFetching && time.Since(agentInfoFetched) > agentInfoTTL {
+		agentInfoFetching = true
+		go func() {
+			info, err := client.Info()
+			app.QueueUpdate(func() {
+				if err == nil {
+					agentInfo = &info
+				}
+				agentInfoErr, agentInfoFetched, agentInfoFetching = err, time.Now(), false
+			})
+		}()
+	}
+	title := fmt.Sprintf("🖧 %s (%s) via %s", agentInfo.Hostname, agentInfo.OS, client.Address)
+	return Utils.RenderDiskStats(statsView, app, title, agentInfo.Partitions, agentInfoErr)
+}
@@ -0,0 +1,48 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/rivo/tview"
+)
+
+// helpTable lists the actions and their keys
+var helpTable *tview.Table
+
+// showHelpPane lists every action with the keys of the active keymap
+func showHelpPane() {
+	if helpTable == nil {
+		helpTable = tview.NewTable().
+			SetSelectable(true, false)
+		helpTable.SetBorder(true)
+	}
+
+	helpTable.Clear()
+	helpTable.SetTitle(fmt.Sprintf(" Keys "+theme.Tag(theme.Muted)+"(%s) ", tview.Escape(keyHint(actionHelp, "Close"))))
+	row := 0
+	addActions := func(list []actionInfo) {
+		for _, action := range list {
+			keys := actionKeyText(action.name, false)
+			if keys == "" {
+				keys = theme.Tag(theme.Muted) + "unbound"
+			} else {
+				keys = theme.Tag(theme.Heading) + tview.Escape(keys)
+			}
+			helpTable.SetCell(row, 0, tview.NewTableCell(keys))
+			helpTable.SetCell(row, 1, tview.NewTableCell(action.description).SetExpansion(1))
+			helpTable.SetCell(row, 2, tview.NewTableCell(action.name).SetTextColor(theme.Muted.TextColor))
+			row++
+		}
+	}
+	addActions(actions)
+
+	// The panes also close with the keys that opened them
+	row++
+	helpTable.SetCell(row, 0, tview.NewTableCell(theme.Tag(theme.Title)+"In analysis panes").SetSelectable(false))
+	row++
+	addActions(paneActions)
+	helpTable.ScrollToBeginning()
+	helpTable.Select(0, 0)
+
+	showPane("help", helpTable)
+}
@@ -0,0 +1,496 @@
+package app
+
+import (
+	"DiskSizer/Utils"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Actions of the tree view that can be bound to keys
+const (
+	actionOpen          = "open"
+	actionBack          = "back"
+	actionUp            = "up"
+	actionDown          = "down"
+	actionCollapse      = "collapse"
+	actionExpand        = "expand"
+	actionTop           = "top"
+	actionBottom        = "bottom"
+	actionPageUp        = "pageUp"
+	actionPageDown      = "pageDown"
+	actionSearch        = "search"
+	actionNextMatch     = "nextMatch"
+	actionPreviousMatch = "previousMatch"
+	actionGoToPath      = "goToPath"
+	actionSetRoot       = "setRoot"
+	actionRootUp        = "rootUp"
+	actionPartitions    = "partitions"
+	actionTreemap       = "treemap"
+	actionSizeBars      = "sizeBars"
+	actionFileTypes     = "fileTypes"
+	actionOwners        = "owners"
+	actionAges          = "ages"
+	actionDuplicates    = "duplicates"
+	actionEmpty         = "empty"
+	actionReclaimable   = "reclaimable"
+	actionGitRepo       = "gitRepo"
+	actionClearFilter   = "clearFilter"
+	actionSaveSnapshot  = "saveSnapshot"
+	actionCompare       = "compare"
+	actionDelete        = "delete"
+	actionQuit          = "quit"
+	actionRefresh       = "refresh"
+	actionEstimate      = "estimate"
+	actionStopScan      = "stopScan"
+	actionClearCache    = "clearCache"
+	actionHelp          = "help"
+)
+
+// Actions of the analysis panes that can be bound to keys
+const (
+	actionSwitchView = "switchView"
+	actionChangeSort = "changeSort"
+	actionStaleDays  = "staleDays"
+	actionCleanUp    = "cleanUp"
+	actionHardLink   = "hardLink"
+)
+
+// actionInfo describes an action for the footer and the help
+type actionInfo struct {
+	name        string
+	description string
+	footer      bool // Listed in the footer, not only in the help
+}
+
+// actions in the order of the footer and the help
+var actions = []actionInfo{
+	{actionOpen, "Open/Collapse", true},
+	{actionBack, "Back", true},
+	{actionUp, "Up", false},
+	{actionDown, "Down", false},
+	{actionCollapse, "Collapse or Go to Parent", false},
+	{actionExpand, "Expand or Go to First Child", false},
+	{actionTop, "Top", false},
+	{actionBottom, "Bottom", false},
+	{actionPageUp, "Page Up", false},
+	{actionPageDown, "Page Down", false},
+	{actionSearch, "Search", true},
+	{actionNextMatch, "Next Match", true},
+	{actionPreviousMatch, "Previous Match", false},
+	{actionGoToPath, "Go to Path", true},
+	{actionSetRoot, "Set Root", true},
+	{actionRootUp, "Root Up", true},
+	{actionPartitions, "Partitions", true},
+	{actionTreemap, "Treemap", true},
+	{actionSizeBars, "Size Bars", true},
+	{actionFileTypes, "File Types", true},
+	{actionOwners, "Owners", true},
+	{actionAges, "Ages", true},
+	{actionDuplicates, "Duplicates", true},
+	{actionEmpty, "Empty", true},
+	{actionReclaimable, "Reclaimable", true},
+	{actionGitRepo, "Git Repo", true},
+	{actionClearFilter, "Clear Filter", false},
+	{actionSaveSnapshot, "Save Snapshot", true},
+	{actionCompare, "Compare", true},
+	{actionDelete, "Delete Through Agent", false},
+	{actionQuit, "Quit", true},
+	{actionRefresh, "Refresh", true},
+	{actionEstimate, "Estimate", false},
+	{actionStopScan, "Stop Scan", false},
+	{actionClearCache, "Clear Cache", true},
+	{actionHelp, "Help", true},
+}
+
+// paneActions in the order of the footer of the panes and the help. The
+// keys of the action that opened a pane close it.
+var paneActions = []actionInfo{
+	{actionSwitchView, "Switch View", true},
+	{actionChangeSort, "Change Sort", true},
+	{actionStaleDays, "Not Modified in N Days", true},
+	{actionCleanUp, "Delete or Clean Up", true},
+	{actionHardLink, "Hard-Link Others", true},
+}
+
+// isPaneAction reports whether action is bound in the analysis panes
+func isPaneAction(action string) bool {
+	for _, info := range paneActions {
+		if info.name == action {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultKeys are the keys of the default keymap. Sequences of keys are
+// separated by spaces, such as "g g".
+var defaultKeys = map[string][]string{
+	actionOpen:          {"enter"},
+	actionBack:          {"backspace"},
+	actionUp:            {"up"},
+	actionDown:          {"down"},
+	actionTop:           {"home"},
+	actionBottom:        {"end"},
+	actionPageUp:        {"pgup"},
+	actionPageDown:      {"pgdn"},
+	actionSearch:        {"/"},
+	actionNextMatch:     {"n"},
+	actionPreviousMatch: {"N"},
+	actionGoToPath:      {"p", "P"},
+	actionSetRoot:       {"r", "R"},
+	actionRootUp:        {"u", "U"},
+	actionPartitions:    {"tab"},
+	actionTreemap:       {"t", "T"},
+	actionSizeBars:      {"b", "B"},
+	actionFileTypes:     {"f", "F"},
+	actionOwners:        {"o", "O"},
+	actionAges:          {"a", "A"},
+	actionDuplicates:    {"d", "D"},
+	actionEmpty:         {"z", "Z"},
+	actionReclaimable:   {"v", "V"},
+	actionGitRepo:       {"i", "I"},
+	actionClearFilter:   {"x", "X"},
+	actionSaveSnapshot:  {"w", "W"},
+	actionCompare:       {"g", "G"},
+	actionDelete:        {"delete"},
+	actionQuit:          {"q", "Q"},
+	actionRefresh:       {"space"},
+	actionEstimate:      {"e", "E"},
+	actionStopScan:      {"s", "S"},
+	actionClearCache:    {"c", "C"},
+	actionHelp:          {"?"},
+	actionSwitchView:    {"m", "M"},
+	actionChangeSort:    {"s", "S"},
+	actionStaleDays:     {"d", "D"},
+	actionCleanUp:       {"x", "X"},
+	actionHardLink:      {"l", "L"},
+}
+
+// vimKeys change the default keymap into the vim preset
+var vimKeys = map[string][]string{
+	actionUp:       {"k", "up"},
+	actionDown:     {"j", "down"},
+	actionCollapse: {"h", "left"},
+	actionExpand:   {"l", "right"},
+	actionTop:      {"g g", "home"},
+	actionBottom:   {"G", "end"},
+	actionPageUp:   {"ctrl-b", "pgup"},
+	actionPageDown: {"ctrl-f", "pgdn"},
+	actionCompare:  {"g c"},
+}
+
+// keymaps are the presets the config can choose from, as changes to the
+// default keymap
+var keymaps = map[string]map[string][]string{
+	"default": nil,
+	"vim":     vimKeys,
+}
+
+// keyIndex finds the actions of one scope by their keys
+type keyIndex struct {
+	actions  map[string]string // Actions by key or key sequence
+	prefixes map[string]bool   // Beginnings of key sequences
+}
+
+// The active keymap
+var (
+	actionKeys  map[string][]string // Keys of each action
+	treeKeys    keyIndex            // Actions of the tree
+	paneKeys    keyIndex            // Actions of the analysis panes
+	pendingKeys string              // Keys typed so far of a sequence
+)
+
+func init() {
+	indexKeys(bindKeys(nil), nil)
+}
+
+// Precedence of the keys of an action when two actions are bound to a key
+const (
+	keysDefault = iota
+	keysPreset
+	keysConfigured
+)
+
+// setKeys activates a keymap preset, which must exist, with the given keys
+// replacing those of their actions
+func setKeys(keymap string, keys map[string]Utils.KeyList) error {
+	preset := keymaps[keymap]
+
+	known := make(map[string]bool, len(actions)+len(paneActions))
+	for _, action := range actions {
+		known[action.name] = true
+	}
+	for _, action := range paneActions {
+		known[action.name] = true
+	}
+	for action, list := range keys {
+		if !known[action] {
+			return fmt.Errorf("unknown action %q", action)
+		}
+		for _, sequence := range list {
+			for _, key := range strings.Fields(sequence) {
+				if !validKeyName(key) {
+					return fmt.Errorf("action %s: unknown key %q", action, key)
+				}
+			}
+		}
+	}
+
+	bound := bindKeys(preset)
+	precedence := make(map[string]int)
+	for action := range preset {
+		precedence[action] = keysPreset
+	}
+	for action, list := range keys {
+		bound[action] = nil
+		for _, sequence := range list {
+			bound[action] = append(bound[action], strings.Join(strings.Fields(sequence), " "))
+		}
+		precedence[action] = keysConfigured
+	}
+	return indexKeys(bound, precedence)
+}
+
+// bindKeys returns the default keys of every action with those of preset
+// replacing them
+func bindKeys(preset map[string][]string) map[string][]string {
+	bound := make(map[string][]string, len(actions))
+	for action, keys := range defaultKeys {
+		bound[action] = keys
+	}
+	for action, keys := range preset {
+		bound[action] = keys
+	}
+	return bound
+}
+
+// indexKeys makes bound the active keymap. A key bound to two actions of
+// the tree, or two of the panes, goes to the one of higher precedence. As
+// the panes also take the keys of the tree, a key cannot start a sequence of
+// either.
+func indexKeys(bound map[string][]string, precedence map[string]int) error {
+	tree := make(map[string][]string)
+	pane := make(map[string][]string)
+	for action, sequences := range bound {
+		if isPaneAction(action) {
+			pane[action] = sequences
+		} else {
+			tree[action] = sequences
+		}
+	}
+	treeIndex := newKeyIndex(tree, precedence)
+	paneIndex := newKeyIndex(pane, precedence)
+
+	indexes := []keyIndex{treeIndex, paneIndex}
+	for _, index := range indexes {
+		for prefix := range index.prefixes {
+			for _, other := range indexes {
+				if action, found := other.actions[prefix]; found {
+					return fmt.Errorf("key %q of %s starts a key sequence of another action", prefix, action)
+				}
+			}
+		}
+	}
+
+	for action, sequences := range pane {
+		tree[action] = sequences
+	}
+	actionKeys, treeKeys, paneKeys, pendingKeys = tree, treeIndex, paneIndex, ""
+	return nil
+}
+
+// newKeyIndex indexes the keys of bound and drops the keys taken over by
+// actions of higher precedence from bound
+func newKeyIndex(bound map[string][]string, precedence map[string]int) keyIndex {
+	index := keyIndex{actions: make(map[string]string), prefixes: make(map[string]bool)}
+
+	// Actions of higher precedence are indexed last, so they keep their keys
+	names := make([]string, 0, len(bound))
+	for action := range bound {
+		names = append(names, action)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if precedence[names[i]] != precedence[names[j]] {
+			return precedence[names[i]] < precedence[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	for _, action := range names {
+		for _, sequence := range bound[action] {
+			index.actions[sequence] = action
+			keys := strings.Fields(sequence)
+			for i := 1; i < len(keys); i++ {
+				index.prefixes[strings.Join(keys[:i], " ")] = true
+			}
+		}
+	}
+
+	// Drop the keys that were taken over
+	for action, sequences := range bound {
+		var kept []string
+		for _, sequence := range sequences {
+			if index.actions[sequence] == action {
+				kept = append(kept, sequence)
+			}
+		}
+		bound[action] = kept
+	}
+	return index
+}
+
+// keyName names the key of event: the character typed, "space", or the
+// lower case tcell name such as "tab" or "ctrl-d"
+func keyName(event *tcell.EventKey) string {
+	switch event.Key() {
+	case tcell.KeyRune:
+		if event.Rune() == ' ' {
+			return "space"
+		}
+		return string(event.Rune())
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		return "backspace"
+	}
+	return strings.ToLower(tcell.KeyNames[event.Key()])
+}
+
+// validKeyName reports whether key names a key that keyName can return
+func validKeyName(key string) bool {
+	if len([]rune(key)) == 1 || key == "space" || key == "backspace" {
+		return true
+	}
+	for _, name := range tcell.KeyNames {
+		if strings.ToLower(name) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// keyAction returns the action of the tree bound to the key of event, or to
+// the sequence it ends. It returns "" for unbound keys, and while a sequence
+// is being typed, in which case consumed is true.
+func keyAction(event *tcell.EventKey) (action string, consumed bool) {
+	keys, consumed := typedKeys(event, treeKeys)
+	return treeKeys.actions[keys], consumed
+}
+
+// typedKeys returns the key of event, or the sequence of keys it ends if
+// one of indexes binds that sequence. It returns "" while a sequence is being
+// typed, and consumed is true for both.
+func typedKeys(event *tcell.EventKey, indexes ...keyIndex) (keys string, consumed bool) {
+	key := keyName(event)
+	if pendingKeys != "" {
+		sequence := pendingKeys + " " + key
+		pendingKeys = ""
+		for _, index := range indexes {
+			if _, found := index.actions[sequence]; found {
+				return sequence, true
+			}
+		}
+		for _, index := range indexes {
+			if index.prefixes[sequence] {
+				pendingKeys = sequence
+				return "", true
+			}
+		}
+	}
+	for _, index := range indexes {
+		if index.prefixes[key] {
+			pendingKeys = key
+			return "", true
+		}
+	}
+	return key, false
+}
+
+// displayKey writes a key or sequence the way the footer shows it, with
+// letters bound in both cases in upper case
+func displayKey(sequence string, sequences []string) string {
+	keys := strings.Fields(sequence)
+	for i, key := range keys {
+		runes := []rune(key)
+		if len(runes) > 1 {
+			keys[i] = strings.ToUpper(key)
+		}
+	}
+	display := strings.Join(keys, "")
+	if len(keys) > 1 {
+		return display
+	}
+
+	if runes := []rune(display); len(runes) == 1 && unicode.IsLower(runes[0]) {
+		for _, other := range sequences {
+			if other == strings.ToUpper(display) {
+				return other
+			}
+		}
+	}
+	return display
+}
+
+// actionKeyText returns the keys of an action as the help shows them, or
+// only the first one for the footer
+func actionKeyText(action string, first bool) string {
+	sequences := actionKeys[action]
+	var shown []string
+	seen := make(map[string]bool)
+	for _, sequence := range sequences {
+		display := displayKey(sequence, sequences)
+		if seen[display] {
+			continue
+		}
+		seen[display] = true
+		shown = append(shown, display)
+		if first {
+			break
+		}
+	}
+	return strings.Join(shown, ", ")
+}
+
+// footerText lists the keys of the actions shown in the footer of the tree,
+// or of the given pane
+func footerText(pane string) string {
+	var items []string
+	if pane == "" {
+		for _, action := range actions {
+			if action.footer {
+				items = append(items, keyHint(action.name, action.description))
+			}
+		}
+		return keyHints(items...)
+	}
+
+	for _, action := range paneActions {
+		if _, handled := paneHandlers[pane][action.name]; handled {
+			items = append(items, keyHint(action.name, action.description))
+		}
+	}
+	items = append(items, keyHint(paneOpeners[pane], "Close"), keyHint(actionQuit, "Quit"))
+	return keyHints(items...)
+}
+
+// keyHint returns the first key of action with a description of what it
+// does, or "" if the action has no keys
+func keyHint(action, description string) string {
+	if len(actionKeys[action]) == 0 {
+		return ""
+	}
+	return actionKeyText(action, true) + ": " + description
+}
+
+// keyHints joins the hints that are not empty
+func keyHints(hints ...string) string {
+	var shown []string
+	for _, hint := range hints {
+		if hint != "" {
+			shown = append(shown, hint)
+		}
+	}
+	return strings.Join(shown, " | ")
+}
@@ -0,0 +1,118 @@
+package app
+
+import (
+	"DiskSizer/Utils"
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// resetKeys restores the default keymap after a test
+func resetKeys(t *testing.T) {
+	t.Cleanup(func() {
+		if err := setKeys("default", nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+// typeKey returns the event of typing r
+func typeKey(r rune) *tcell.EventKey {
+	return tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone)
+}
+
+func TestSetKeysConflicts(t *testing.T) {
+	resetKeys(t)
+
+	tests := []struct {
+		keymap string
+		keys   map[string]Utils.KeyList
+		err    string
+	}{
+		// g is the key of compare, so it cannot start a sequence
+		{"default", map[string]Utils.KeyList{actionTop: {"g g"}}, `key "g" of compare starts a key sequence`},
+		// Panes take the keys of the tree as well
+		{"default", map[string]Utils.KeyList{actionCleanUp: {"q x"}}, `key "q" of quit starts a key sequence`},
+		{"vim", map[string]Utils.KeyList{actionHardLink: {"g"}}, `key "g" of hardLink starts a key sequence`},
+		{"default", map[string]Utils.KeyList{"nothing": {"y"}}, `unknown action "nothing"`},
+		{"default", map[string]Utils.KeyList{actionQuit: {"ctrl-nothing"}}, `unknown key "ctrl-nothing"`},
+	}
+	for _, test := range tests {
+		err := setKeys(test.keymap, test.keys)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("setKeys(%s, %v) = %v, want %q", test.keymap, test.keys, err, test.err)
+		}
+	}
+
+	if err := setKeys("vim", map[string]Utils.KeyList{actionHelp: {"f"}}); err != nil {
+		t.Fatal(err)
+	}
+	// A configured key takes over the key of another action
+	if treeKeys.actions["f"] != actionHelp || treeKeys.actions["F"] != actionFileTypes {
+		t.Errorf("f is bound to %q and F to %q, want help and fileTypes", treeKeys.actions["f"], treeKeys.actions["F"])
+	}
+	// Keys of the tree and of the panes do not take over each other
+	if treeKeys.actions["l"] != actionExpand || paneKeys.actions["l"] != actionHardLink {
+		t.Errorf("l is bound to %q in the tree and %q in panes, want expand and hardLink", treeKeys.actions["l"], paneKeys.actions["l"])
+	}
+}
+
+func TestKeyActionSequences(t *testing.T) {
+	resetKeys(t)
+	if err := setKeys("vim", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	type typed struct {
+		key      rune
+		action   string
+		consumed bool
+	}
+	tests := [][]typed{
+		{{'g', "", true}, {'g', actionTop, true}},
+		{{'g', "", true}, {'c', actionCompare, true}},
+		{{'G', actionBottom, false}},
+		{{'j', actionDown, false}, {'k', actionUp, false}},
+		// A key that does not go on with the sequence counts by itself
+		{{'g', "", true}, {'x', actionClearFilter, false}},
+		{{'g', "", true}, {'y', "", false}, {'j', actionDown, false}},
+	}
+	for _, sequence := range tests {
+		for _, step := range sequence {
+			action, consumed := keyAction(typeKey(step.key))
+			if action != step.action || consumed != step.consumed {
+				t.Errorf("%v: typing %c gave %q, %v, want %q, %v", sequence, step.key, action, consumed, step.action, step.consumed)
+			}
+		}
+	}
+}
+
+func TestTypedKeysInPanes(t *testing.T) {
+	resetKeys(t)
+	if err := setKeys("vim", map[string]Utils.KeyList{actionSwitchView: {"m m"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Panes look up sequences of both the pane actions and the tree
+	for _, test := range []struct {
+		keys string
+		want string
+	}{
+		{"mm", "m m"},
+		{"gc", "g c"},
+		{"l", "l"},
+	} {
+		var keys string
+		var consumed bool
+		for _, r := range test.keys {
+			keys, consumed = typedKeys(typeKey(r), paneKeys, treeKeys)
+		}
+		if keys != test.want || consumed != (len(test.keys) > 1) {
+			t.Errorf("typing %s gave %q, %v, want %q", test.keys, keys, consumed, test.want)
+		}
+	}
+	if paneKeys.actions["m m"] != actionSwitchView || treeKeys.actions["g c"] != actionCompare {
+		t.Error("sequences are not bound to their actions")
+	}
+}
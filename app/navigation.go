@@ -1,9 +1,116 @@
 package app
 
 import (
+	cache "DiskSizer/Cache"
+	"DiskSizer/Utils"
+	"DiskSizer/styling"
+	"fmt"
 	"path/filepath"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
 )
 
+// setTreeRoot replaces the tree root with an empty node for path
+func setTreeRoot(path string) *tview.TreeNode {
+	root := tview.NewTreeNode(filepath.Base(path)).
+		SetReference(path).
+		SetSelectable(true).
+		SetColor(theme.Directory.TextColor)
+	treeView.SetRoot(root).SetCurrentNode(root)
+	CurrentPath = path
+	updateBreadcrumb()
+	return root
+}
+
+// updateBreadcrumb shows the current tree root as a breadcrumb trail
+func updateBreadcrumb() {
+	rootPath := treeView.GetRoot().GetReference().(string)
+
+	volume := filepath.VolumeName(rootPath)
+	rest := strings.TrimPrefix(rootPath, volume)
+
+	var parts []string
+	for _, part := range strings.Split(rest, string(filepath.Separator)) {
+		if part != "" {
+			parts = append(parts, tview.Escape(part))
+		}
+	}
+
+	crumbs := theme.Tag(theme.Muted) + "Root: [-:-:-]" + tview.Escape(volume) + string(filepath.Separator)
+	if len(parts) > 0 {
+		crumbs += " " + theme.Tag(theme.Muted) + "›[-:-:-] " + strings.Join(parts, " "+theme.Tag(theme.Muted)+"›[-:-:-] ")
+	}
+	if treeFilterLabel != "" {
+		crumbs += "  " + theme.Tag(theme.Warning) + "(showing " + tview.Escape(treeFilterLabel) + filterHint()
+	}
+	if searchFiltered {
+		crumbs += "  " + theme.Tag(theme.Warning) + "(matching " + tview.Escape(fmt.Sprintf("%q", searchQuery)) + filterHint()
+	}
+	breadcrumbView.SetText(crumbs)
+}
+
+// filterHint ends the description of a filter in the breadcrumb with the key
+// that clears it
+func filterHint() string {
+	if hint := keyHint(actionClearFilter, "Clear Filter"); hint != "" {
+		return ", " + tview.Escape(hint) + ")"
+	}
+	return ")"
+}
+
+// rerootAtSelection makes the selected directory the new tree root
+func rerootAtSelection() {
+	node := treeView.GetCurrentNode()
+	if node == nil || node.GetReference() == nil {
+		return
+	}
+
+	path := node.GetReference().(string)
+	info, err := fsys.Stat(path)
+	if err != nil || !info.IsDir {
+		statsView.SetText(theme.Tag(theme.Warning) + "Only directories can become the tree root")
+		return
+	}
+
+	root := setTreeRoot(path)
+	addChildren(root)
+	updateStats()
+}
+
+// rerootUp makes the parent of the current tree root the new root. Only the
+// siblings of the old root are scanned; its own subtree comes from the cache.
+func rerootUp() {
+	oldRoot := treeView.GetRoot().GetReference().(string)
+	parentPath := filepath.Dir(oldRoot)
+	if parentPath == oldRoot {
+		return
+	}
+
+	statsView.SetText(fmt.Sprintf(theme.Tag(theme.Warning)+"Scanning %s...", parentPath))
+
+	go func() {
+		var err error
+		if !isLocal() {
+			_, _, err = cachedScan(parentPath)
+		} else {
+			_, _, err = cache.CachedScanChildren(Utils.NewOSFileSystem(parentPath), parentPath, &processedSize, dirCache)
+		}
+		app.QueueUpdateDraw(func() {
+			if err != nil {
+				statsView.SetText(fmt.Sprintf(theme.Tag(theme.Error)+"Error scanning %s: %v", parentPath, err))
+				return
+			}
+
+			root := setTreeRoot(parentPath)
+			ensureChildrenLoaded(root)
+			revealPath(oldRoot)
+			updateStats()
+		})
+	}()
+}
+
 // navigateUp moves up one directory in the tree view
 func navigateUp() {
 	currentNode := treeView.GetCurrentNode()
@@ -30,6 +137,12 @@ func navigateUp() {
 		return
 	}
 
+	// Going up from the root moves the root itself up one level
+	if currentNode == rootNode {
+		rerootUp()
+		return
+	}
+
 	parentNode := findNodeByPath(rootNode, parentPath)
 	if parentNode != nil {
 		treeView.SetCurrentNode(parentNode)
@@ -53,3 +166,80 @@ func refreshCurrentDir() {
 	// Add new children with fresh scan
 	addChildren(currentNode)
 }
+
+// explorePartition re-roots the tree at a mountpoint selected in the stats
+// panel and starts scanning it
+func explorePartition(mountpoint string) {
+	statsView.Highlight()
+	app.SetFocus(treeView)
+
+	root := setTreeRoot(mountpoint)
+	addChildren(root)
+}
+
+// togglePartitionFocus switches the focus between the tree and the partition
+// list in the stats panel
+func togglePartitionFocus() {
+	if app.GetFocus() == statsView {
+		statsView.Highlight()
+		app.SetFocus(treeView)
+		return
+	}
+
+	app.SetFocus(statsView)
+	styling.SelectRegion(statsView, 0)
+}
+
+// treeMoves are the keys of the tree view the movement actions stand for
+var treeMoves = map[string]tcell.Key{
+	actionOpen:     tcell.KeyEnter,
+	actionUp:       tcell.KeyUp,
+	actionDown:     tcell.KeyDown,
+	actionTop:      tcell.KeyHome,
+	actionBottom:   tcell.KeyEnd,
+	actionPageUp:   tcell.KeyPgUp,
+	actionPageDown: tcell.KeyPgDn,
+}
+
+// moveInTree performs a movement action in the tree, mostly by handing the
+// tree view the key it stands for. Other views get the original key.
+func moveInTree(action string, event *tcell.EventKey) *tcell.EventKey {
+	if app.GetFocus() != treeView {
+		return event
+	}
+	node := treeView.GetCurrentNode()
+
+	switch action {
+	case actionCollapse:
+		// Collapse an open directory, or go to the parent node
+		if node == nil {
+			return nil
+		}
+		if node.IsExpanded() && len(node.GetChildren()) > 0 {
+			node.Collapse()
+		} else if nodes := treeView.GetPath(node); len(nodes) > 1 {
+			treeView.SetCurrentNode(nodes[len(nodes)-2])
+		}
+		return nil
+	case actionExpand:
+		// Open a closed directory, or go to its first child
+		if node == nil || node.GetReference() == nil {
+			return nil
+		}
+		path := node.GetReference().(string)
+		if info, err := fsys.Stat(path); err != nil || !info.IsDir {
+			return nil
+		}
+		if node.IsExpanded() && len(node.GetChildren()) > 0 {
+			return tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)
+		}
+		node.SetExpanded(true)
+		if len(node.GetChildren()) == 0 {
+			addChildren(node)
+		}
+		CurrentPath = path
+		updateStats()
+		return nil
+	}
+	return tcell.NewEventKey(treeMoves[action], 0, tcell.ModNone)
+}
@@ -0,0 +1,82 @@
+package app
+
+import (
+	"DiskSizer/Utils"
+	"DiskSizer/styling"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+)
+
+// snapshotFileSystem shows a saved scan instead of a live file system. It
+// is read-only.
+type snapshotFileSystem struct {
+	snapshot Utils.Snapshot
+	source   string
+}
+
+// OpenSnapshot makes the tree show a saved scan in offline view mode. source
+// describes where it came from. Call it before StartApp.
+func OpenSnapshot(snapshot Utils.Snapshot, source string) {
+	fsys = &snapshotFileSystem{snapshot: snapshot, source: source}
+	defaultRoot = snapshot.Path
+}
+
+// find returns the entry at path in the snapshot
+func (s *snapshotFileSystem) find(path string) (Utils.DirEntry, error) {
+	root := s.snapshot.Root
+	rel, err := filepath.Rel(root.Path, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return Utils.DirEntry{}, fmt.Errorf("%s is not part of the snapshot of %s", path, root.Path)
+	}
+	if rel == "." {
+		return root, nil
+	}
+
+	entry := root
+	for _, name := range strings.Split(rel, string(filepath.Separator)) {
+		found := false
+		for _, child := range entry.Children {
+			if child.Name == name {
+				entry, found = child, true
+				break
+			}
+		}
+		if !found {
+			return Utils.DirEntry{}, fmt.Errorf("%s is not part of the snapshot", path)
+		}
+	}
+	return entry, nil
+}
+
+func (s *snapshotFileSystem) ScanDir(path string, processedSize *int64) (Utils.DirEntry, int64, error) {
+	entry, err := s.find(path)
+	if err != nil {
+		return Utils.DirEntry{Path: path}, 0, err
+	}
+	atomic.AddInt64(processedSize, entry.Size)
+	return entry, 0, nil
+}
+
+func (s *snapshotFileSystem) Stat(path string) (Utils.DirEntry, error) {
+	entry, err := s.find(path)
+	entry.Children = nil
+	return entry, err
+}
+
+func (s *snapshotFileSystem) Delete(path string) error {
+	return fmt.Errorf("snapshots are read-only")
+}
+
+// stats describes the snapshot in place of the partition list
+func (s *snapshotFileSystem) stats() string {
+	var result strings.Builder
+	result.WriteString(styling.CreateHeader("📴 Offline view of " + s.source))
+	result.WriteString("\n" + styling.CreateInfoText("Scanned", s.snapshot.ScannedAt.Format("2006-01-02 15:04"), theme.Text))
+	result.WriteString("   |   " + styling.CreateInfoText("Total", Utils.FormatSize(s.snapshot.Root.Size), theme.Text))
+	if s.snapshot.Skipped > 0 {
+		result.WriteString("   |   " + styling.CreateInfoText("Skipped", Utils.FormatSize(s.snapshot.Skipped), theme.Warning.TextColor))
+	}
+	return result.String()
+}
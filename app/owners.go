@@ -0,0 +1,85 @@
+package app
+
+import (
+	"DiskSizer/Utils"
+	"fmt"
+
+	"github.com/rivo/tview"
+)
+
+// Owner pane state
+var (
+	ownersTable  *tview.Table
+	ownersEntry  Utils.DirEntry
+	ownersByUser = true
+	ownersSort   = Utils.SortBySize
+)
+
+// showOwnersPane shows the usage per user or group of the current directory
+func showOwnersPane() {
+	entry, found := currentDirEntry()
+	if !found {
+		return
+	}
+	ownersEntry = entry
+
+	if ownersTable == nil {
+		ownersTable = newUsageTable()
+
+		// Enter limits the tree to the files of the selected owner
+		ownersTable.SetSelectedFunc(func(row, column int) {
+			ref := ownersTable.GetCell(row, 0).GetReference()
+			if ref == nil {
+				return
+			}
+			filterByOwner(ref.(string), ownersByUser)
+			closePane()
+		})
+
+		paneHandlers["owners"] = map[string]func(){
+			actionSwitchView: func() {
+				ownersByUser = !ownersByUser
+				renderOwnersTable()
+			},
+			actionChangeSort: func() {
+				ownersSort = nextSortOrder(ownersSort)
+				renderOwnersTable()
+			},
+		}
+	}
+
+	renderOwnersTable()
+	showPane("owners", ownersTable)
+}
+
+// renderOwnersTable fills the owner table for ownersEntry
+func renderOwnersTable() {
+	byUser, byGroup := Utils.OwnerBreakdown(ownersEntry)
+	stats, keyTitle := byGroup, "Group"
+	if ownersByUser {
+		stats, keyTitle = byUser, "User"
+	}
+	Utils.SortUsageStats(stats, ownersSort)
+
+	ownersTable.SetTitle(fmt.Sprintf(" Owners in %s "+theme.Tag(theme.Muted)+"(%s) ",
+		tview.Escape(ownersEntry.Path), tview.Escape(keyHints(
+			"ENTER: Show Only Their Files",
+			keyHint(actionSwitchView, "User/Group"),
+			keyHint(actionChangeSort, fmt.Sprintf("Sort by %s", ownersSort)),
+			keyHint(actionOwners, "Close")))))
+	renderUsageTable(ownersTable, keyTitle, stats, ownersEntry.Size, nil)
+}
+
+// filterByOwner limits the tree to files owned by the named user or group
+func filterByOwner(name string, byUser bool) {
+	if byUser {
+		setTreeFilter("files of user "+name, func(e Utils.DirEntry) bool {
+			return Utils.UserName(e.UID) == name
+		})
+		return
+	}
+
+	setTreeFilter("files of group "+name, func(e Utils.DirEntry) bool {
+		return Utils.GroupName(e.GID) == name
+	})
+}
@@ -0,0 +1,151 @@
+package app
+
+import (
+	cache "DiskSizer/Cache"
+	"DiskSizer/Utils"
+	"DiskSizer/styling"
+	"fmt"
+	"path/filepath"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// paneOpeners are the actions that open each pane, and close it again
+var paneOpeners = map[string]string{
+	"treemap": actionTreemap,
+	"types":   actionFileTypes,
+	"owners":  actionOwners,
+	"ages":    actionAges,
+	"dupes":   actionDuplicates,
+	"empty":   actionEmpty,
+	"reclaim": actionReclaimable,
+	"repo":    actionGitRepo,
+	"diff":    actionCompare,
+	"help":    actionHelp,
+}
+
+// paneHandlers run the pane actions each pane supports, by pane name and
+// action
+var paneHandlers = make(map[string]map[string]func())
+
+// showPane replaces the tree with an analysis pane and focuses it
+func showPane(name string, item tview.Primitive) {
+	if !mainPages.HasPage(name) {
+		mainPages.AddPage(name, item, true, false)
+	}
+	mainPages.SwitchToPage(name)
+	app.SetFocus(item)
+	updateFooter()
+}
+
+// handlePaneKey runs the action bound to the key of event in the pane
+// that is shown. The keys of the action that opened the pane close it.
+func handlePaneKey(event *tcell.EventKey) *tcell.EventKey {
+	name, _ := mainPages.GetFrontPage()
+	if event.Key() == tcell.KeyEscape {
+		closePane()
+		return nil
+	}
+
+	keys, consumed := typedKeys(event, paneKeys, treeKeys)
+	if handler, found := paneHandlers[name][paneKeys.actions[keys]]; found {
+		handler()
+		return nil
+	}
+	switch action := treeKeys.actions[keys]; {
+	case action != "" && action == paneOpeners[name]:
+		closePane()
+		return nil
+	case action == actionQuit:
+		app.Stop()
+		return nil
+	}
+	if consumed {
+		return nil
+	}
+	return event
+}
+
+// updateFooter lists the keys of the pane that is shown, or of the tree
+func updateFooter() {
+	name, _ := mainPages.GetFrontPage()
+	if name == "tree" {
+		name = ""
+	}
+	footerView.SetText(styling.ApplyStyle(tview.Escape(footerText(name)), theme.Footer))
+}
+
+// paneShown reports whether an analysis pane is covering the tree
+func paneShown() bool {
+	name, _ := mainPages.GetFrontPage()
+	return name != "tree"
+}
+
+// closePane goes back from an analysis pane to the tree and reveals the
+// directory the pane was showing
+func closePane() {
+	mainPages.SwitchToPage("tree")
+	app.SetFocus(treeView)
+	updateFooter()
+	revealPath(CurrentPath)
+	updateStats()
+}
+
+// currentDirEntry returns the scanned entry of the current directory, or of
+// the directory containing the current path if that is a file
+func currentDirEntry() (Utils.DirEntry, bool) {
+	cachedEntry, found := dirCache.Lookup(CurrentPath)
+	if found && !cachedEntry.IsDir {
+		cachedEntry, found = dirCache.Lookup(filepath.Dir(CurrentPath))
+	}
+	if !found {
+		statsView.SetText(theme.Tag(theme.Warning) + "This directory has not been scanned yet")
+		return Utils.DirEntry{}, false
+	}
+	return cache.ToUtilsDirEntry(cachedEntry), true
+}
+
+// newUsageTable creates the table used by the breakdown panes
+func newUsageTable() *tview.Table {
+	table := tview.NewTable().
+		SetFixed(1, 0).
+		SetSelectable(true, false)
+	table.SetBorder(true)
+	return table
+}
+
+// renderUsageTable fills table with one row per stat and its share of total.
+// label may decorate the key shown in the first column.
+func renderUsageTable(table *tview.Table, keyTitle string, stats []Utils.UsageStat, total int64, label func(key string) string) {
+	table.Clear()
+
+	headers := []string{keyTitle, "Files", "Size", "Share"}
+	for col, title := range headers {
+		table.SetCell(0, col, tview.NewTableCell(title).
+			SetTextColor(theme.Heading.TextColor).
+			SetAttributes(tcell.AttrBold).
+			SetSelectable(false))
+	}
+
+	for i, stat := range stats {
+		row := i + 1
+		key := stat.Key
+		if label != nil {
+			key = label(stat.Key)
+		}
+
+		ratio := 0.0
+		if total > 0 {
+			ratio = float64(stat.Size) / float64(total)
+		}
+		barColor := theme.SizeColor(stat.Size, total)
+
+		table.SetCell(row, 0, tview.NewTableCell(tview.Escape(key)).SetReference(stat.Key))
+		table.SetCell(row, 1, tview.NewTableCell(fmt.Sprintf("%d", stat.Count)).SetAlign(tview.AlignRight))
+		table.SetCell(row, 2, tview.NewTableCell(Utils.FormatSize(stat.Size)).SetAlign(tview.AlignRight))
+		table.SetCell(row, 3, tview.NewTableCell(styling.CreateSizeBar(ratio, 20, barColor)))
+	}
+
+	table.Select(1, 0).ScrollToBeginning()
+}
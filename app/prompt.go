@@ -0,0 +1,57 @@
+package app
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// promptInput is the input field currently shown in place of the footer
+var promptInput *tview.InputField
+
+// showPrompt replaces the footer with an input field. changed is called on
+// every keystroke (it may be nil), done is called with the text on Enter.
+// Escape closes the prompt and calls done with ok set to false.
+func showPrompt(label, initial string, changed func(text string), done func(text string, ok bool)) {
+	if promptInput != nil {
+		closePrompt()
+	}
+
+	promptInput = tview.NewInputField().
+		SetLabel(label).
+		SetText(initial).
+		SetFieldBackgroundColor(tcell.ColorDefault).
+		SetLabelColor(theme.Heading.TextColor)
+
+	if changed != nil {
+		promptInput.SetChangedFunc(changed)
+	}
+
+	promptInput.SetDoneFunc(func(key tcell.Key) {
+		text := promptInput.GetText()
+		closePrompt()
+		switch key {
+		case tcell.KeyEnter:
+			done(text, true)
+		case tcell.KeyEscape:
+			done(text, false)
+		}
+	})
+
+	flex.RemoveItem(footerView)
+	flex.AddItem(promptInput, 1, 0, true)
+	app.SetFocus(promptInput)
+}
+
+// closePrompt restores the footer and gives focus back to the tree or the
+// pane that is shown in its place
+func closePrompt() {
+	if promptInput == nil {
+		return
+	}
+	flex.RemoveItem(promptInput)
+	flex.AddItem(footerView, 1, 0, false)
+	promptInput = nil
+
+	_, front := mainPages.GetFrontPage()
+	app.SetFocus(front)
+}
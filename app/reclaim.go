@@ -0,0 +1,162 @@
+package app
+
+import (
+	"DiskSizer/Utils"
+	"fmt"
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+// reclaimRules recognize the reclaimable directories badged in the tree
+var reclaimRules = Utils.DefaultReclaimRules()
+
+// Reclaimable space pane state
+var (
+	reclaimTable    *tview.Table
+	reclaimEntry    Utils.DirEntry
+	reclaimMatches  []Utils.ReclaimMatch
+	reclaimCleaning bool // A cleanup is running in the background
+)
+
+// SetReclaimRules replaces the rules that recognize reclaimable directories.
+// Call it before StartApp.
+func SetReclaimRules(rules []Utils.ReclaimRule) {
+	reclaimRules = rules
+}
+
+// reclaimBadge returns the badge of a reclaimable directory on the local
+// disks, or ""
+func reclaimBadge(entry Utils.DirEntry, path string) string {
+	if !entry.IsDir || !isLocal() {
+		return ""
+	}
+	rule, found := Utils.MatchReclaimRule(reclaimRules, path)
+	if !found {
+		return ""
+	}
+	return " " + theme.Tag(theme.ReclaimBadge) + "♻ " + tview.Escape(rule.Name) + "[-:-:-]"
+}
+
+// showReclaimPane lists the reclaimable directories below the current
+// directory with the totals per rule
+func showReclaimPane() {
+	if !localOnly("Reclaiming space") {
+		return
+	}
+	entry, found := currentDirEntry()
+	if !found {
+		return
+	}
+	reclaimEntry = entry
+	reclaimMatches = Utils.FindReclaimable(entry, reclaimRules)
+
+	if reclaimTable == nil {
+		reclaimTable = tview.NewTable().
+			SetSelectable(true, false)
+		reclaimTable.SetBorder(true)
+
+		// Enter reveals the selected directory in the tree
+		reclaimTable.SetSelectedFunc(func(row, column int) {
+			ref := reclaimTable.GetCell(row, 0).GetReference()
+			if ref == nil {
+				return
+			}
+			CurrentPath = ref.(string)
+			closePane()
+		})
+
+		paneHandlers["reclaim"] = map[string]func(){
+			actionCleanUp: cleanupReclaimable,
+		}
+	}
+
+	renderReclaimTable()
+	showPane("reclaim", reclaimTable)
+}
+
+// renderReclaimTable lists the totals per rule followed by the directories
+func renderReclaimTable() {
+	var total int64
+	for _, match := range reclaimMatches {
+		total += match.Size
+	}
+
+	reclaimTable.Clear()
+	reclaimTable.SetTitle(fmt.Sprintf(" Reclaimable in %s: %s "+theme.Tag(theme.Muted)+"(%s) ",
+		tview.Escape(reclaimEntry.Path), Utils.FormatSize(total), tview.Escape(keyHints(
+			"ENTER: Show in Tree",
+			keyHint(actionCleanUp, "Clean Up All"),
+			keyHint(actionReclaimable, "Close")))))
+
+	row := 0
+	reclaimTable.SetCell(row, 0, tview.NewTableCell(theme.Tag(theme.Heading)+"Per rule").SetSelectable(false))
+	row++
+	for _, stat := range Utils.ReclaimTotals(reclaimMatches) {
+		reclaimTable.SetCell(row, 0, tview.NewTableCell("  "+tview.Escape(stat.Key)).SetSelectable(false))
+		reclaimTable.SetCell(row, 1, tview.NewTableCell(fmt.Sprintf("%d dirs", stat.Count)).SetAlign(tview.AlignRight).SetSelectable(false))
+		reclaimTable.SetCell(row, 2, tview.NewTableCell(Utils.FormatSize(stat.Size)).SetAlign(tview.AlignRight).SetSelectable(false))
+		row++
+	}
+
+	reclaimTable.SetCell(row, 0, tview.NewTableCell(fmt.Sprintf(theme.Tag(theme.Heading)+"Directories (%d)", len(reclaimMatches))).SetSelectable(false))
+	row++
+	for _, match := range reclaimMatches {
+		reclaimTable.SetCell(row, 0, tview.NewTableCell("  "+tview.Escape(match.Path)).SetReference(match.Path))
+		reclaimTable.SetCell(row, 1, tview.NewTableCell(tview.Escape(match.Rule)).SetTextColor(theme.Muted.TextColor))
+		reclaimTable.SetCell(row, 2, tview.NewTableCell(Utils.FormatSize(match.Size)).SetAlign(tview.AlignRight))
+		row++
+	}
+
+	reclaimTable.ScrollToBeginning()
+	if len(reclaimMatches) > 0 {
+		reclaimTable.Select(row-len(reclaimMatches), 0)
+	}
+}
+
+// cleanupReclaimable cleans up every listed directory after confirmation
+func cleanupReclaimable() {
+	if reclaimCleaning {
+		statsView.SetText(theme.Tag(theme.Warning) + "A cleanup is already running")
+		return
+	}
+	if len(reclaimMatches) == 0 {
+		return
+	}
+
+	var total int64
+	for _, match := range reclaimMatches {
+		total += match.Size
+	}
+	question := fmt.Sprintf("Clean up %d directories to reclaim %s? (y/n) ", len(reclaimMatches), Utils.FormatSize(total))
+	showPrompt(question, "", nil, func(text string, ok bool) {
+		if !ok || strings.ToLower(strings.TrimSpace(text)) != "y" {
+			return
+		}
+
+		// Cleanup commands and removing large trees take a while
+		reclaimCleaning = true
+		path, matches := reclaimEntry.Path, reclaimMatches
+		statsView.SetText(fmt.Sprintf(theme.Tag(theme.Warning)+"Cleaning up %d directories...", len(matches)))
+
+		go func() {
+			cleaned, err := Utils.CleanReclaimable(reclaimRules, matches)
+			dirCache.Invalidate(path)
+
+			app.QueueUpdateDraw(func() {
+				reclaimCleaning = false
+				if err != nil {
+					statsView.SetText(fmt.Sprintf(theme.Tag(theme.Error)+"Cleaned up %d directories before an error: %v", cleaned, tview.Escape(err.Error())))
+				} else {
+					statsView.SetText(fmt.Sprintf(theme.Tag(theme.Success)+"Cleaned up %d directories. Press SPACE in the tree to rescan.", cleaned))
+				}
+
+				// The pane may show another directory by now
+				if reclaimEntry.Path == path {
+					reclaimMatches = matches[cleaned:]
+					renderReclaimTable()
+				}
+			})
+		}()
+	})
+}
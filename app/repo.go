@@ -0,0 +1,222 @@
+package app
+
+import (
+	"DiskSizer/Utils"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/rivo/tview"
+)
+
+// Git repositories by the directories below their root, nil outside of one
+var (
+	gitRepos      = make(map[string]*Utils.GitRepo)
+	gitReposMutex sync.Mutex
+)
+
+// Git repository pane state
+var (
+	repoTable  *tview.Table
+	repoRoot   string // Root of the repository shown
+	repoReport Utils.GitReport
+)
+
+// gitRepo returns the repository containing dir, or nil
+func gitRepo(dir string) *Utils.GitRepo {
+	gitReposMutex.Lock()
+	defer gitReposMutex.Unlock()
+
+	if repo, found := gitRepos[dir]; found {
+		return repo
+	}
+	var repo *Utils.GitRepo
+	if root, found := Utils.FindGitRepo(dir); found {
+		if root != dir {
+			repo = gitRepos[root]
+		}
+		if repo == nil {
+			repo, _ = Utils.OpenGitRepo(root)
+			gitRepos[root] = repo
+		}
+	}
+	gitRepos[dir] = repo
+	return repo
+}
+
+// clearGitRepos forgets the repositories, so they are read again
+func clearGitRepos() {
+	gitReposMutex.Lock()
+	defer gitReposMutex.Unlock()
+	gitRepos = make(map[string]*Utils.GitRepo)
+}
+
+// gitBadge returns the badge of a repository root, or of the topmost
+// untracked or ignored path of a repository on the local disks, or ""
+func gitBadge(entry Utils.DirEntry, path string) string {
+	if !isLocal() {
+		return ""
+	}
+	if entry.IsDir {
+		if repo := gitRepo(path); repo != nil && repo.Root == path {
+			return " " + theme.Tag(theme.GitBadge) + "⎇ git[-:-:-]"
+		}
+	}
+
+	parent := filepath.Dir(path)
+	repo := gitRepo(parent)
+	if repo == nil {
+		return ""
+	}
+	status := repo.Status(path, entry.IsDir)
+	if parent != repo.Root && repo.Status(parent, true) == status {
+		return ""
+	}
+	switch status {
+	case Utils.GitUntracked:
+		return " " + theme.Tag(theme.Warning) + "untracked[-:-:-]"
+	case Utils.GitIgnored:
+		return " " + theme.Tag(theme.Muted) + "ignored[-:-:-]"
+	}
+	return ""
+}
+
+// showRepoPane breaks down the space of the git repository containing the
+// current directory
+func showRepoPane() {
+	if !localOnly("The git repository view") {
+		return
+	}
+	entry, found := currentDirEntry()
+	if !found {
+		return
+	}
+	repo := gitRepo(entry.Path)
+	if repo == nil {
+		statsView.SetText(fmt.Sprintf(theme.Tag(theme.Warning)+"%s is not in a git repository", tview.Escape(entry.Path)))
+		return
+	}
+
+	if repoTable == nil {
+		repoTable = tview.NewTable().
+			SetSelectable(true, false)
+		repoTable.SetBorder(true)
+
+		// Enter reveals the selected path in the tree
+		repoTable.SetSelectedFunc(func(row, column int) {
+			ref := repoTable.GetCell(row, 0).GetReference()
+			if ref == nil {
+				return
+			}
+			CurrentPath = ref.(string)
+			closePane()
+		})
+	}
+
+	repoRoot = repo.Root
+	repoTable.Clear()
+	repoTable.SetTitle(fmt.Sprintf(" Git repository %s ", tview.Escape(repo.Root)))
+	repoTable.SetCell(0, 0, tview.NewTableCell(theme.Tag(theme.Warning)+"Scanning...").SetSelectable(false))
+	showPane("repo", repoTable)
+
+	// Scanning the work tree and reading the packfiles takes a while on
+	// large repositories
+	go func() {
+		var report Utils.GitReport
+		var err error
+		if entry.Path != repo.Root {
+			entry, _, err = cachedScan(repo.Root)
+		}
+		if err == nil {
+			if report, err = repo.Report(entry, 20); err != nil {
+				err = fmt.Errorf("error reading %s: %v", repo.GitDir, err)
+			}
+		} else {
+			err = fmt.Errorf("error scanning %s: %v", repo.Root, err)
+		}
+
+		app.QueueUpdateDraw(func() {
+			if repoRoot != repo.Root {
+				return
+			}
+			if err != nil {
+				repoTable.SetCell(0, 0, tview.NewTableCell(theme.Tag(theme.Error)+tview.Escape(err.Error())).SetSelectable(false))
+				return
+			}
+			repoReport = report
+			renderRepoTable()
+		})
+	}()
+}
+
+// renderRepoTable lists the parts of the repository, the largest untracked
+// and ignored paths and the largest packed objects
+func renderRepoTable() {
+	report := repoReport
+	workTree := report.Tracked.Size + report.Untracked.Size + report.Ignored.Size
+
+	repoTable.Clear()
+	repoTable.SetTitle(fmt.Sprintf(" Git repository %s: %s "+theme.Tag(theme.Muted)+"(%s) ",
+		tview.Escape(report.Root), Utils.FormatSize(report.GitSize+workTree), tview.Escape(keyHints(
+			"ENTER: Show in Tree",
+			keyHint(actionGitRepo, "Close")))))
+
+	row := 0
+	addRow := func(label, files string, size int64, ref string) {
+		cell := tview.NewTableCell(label)
+		if ref != "" {
+			cell.SetReference(ref)
+		} else {
+			cell.SetSelectable(false)
+		}
+		repoTable.SetCell(row, 0, cell)
+		repoTable.SetCell(row, 1, tview.NewTableCell(files).SetAlign(tview.AlignRight).SetTextColor(theme.Muted.TextColor).SetSelectable(ref != ""))
+		repoTable.SetCell(row, 2, tview.NewTableCell(Utils.FormatSize(size)).SetAlign(tview.AlignRight).SetSelectable(ref != ""))
+		row++
+	}
+	heading := func(title string) {
+		repoTable.SetCell(row, 0, tview.NewTableCell(theme.Tag(theme.Heading)+title).SetSelectable(false))
+		row++
+	}
+	files := func(n int) string {
+		return fmt.Sprintf("%d files", n)
+	}
+
+	heading("Repository")
+	addRow("  .git", "", report.GitSize, report.GitDir)
+	addRow("    packfiles", "", report.PackSize, "")
+	addRow("    loose objects", "", report.LooseSize, "")
+	addRow("  working tree", files(report.Tracked.Files+report.Untracked.Files+report.Ignored.Files), workTree, "")
+	addRow("    tracked", files(report.Tracked.Files), report.Tracked.Size, "")
+	addRow("    untracked", files(report.Untracked.Files), report.Untracked.Size, "")
+	addRow("    ignored", files(report.Ignored.Files), report.Ignored.Size, "")
+
+	for _, section := range []struct {
+		title string
+		stats []Utils.UsageStat
+	}{{"Largest ignored", report.TopIgnored}, {"Largest untracked", report.TopUntracked}} {
+		if len(section.stats) == 0 {
+			continue
+		}
+		heading(section.title)
+		for _, stat := range section.stats {
+			addRow("  "+tview.Escape(stat.Key), files(stat.Count), stat.Size, stat.Key)
+		}
+	}
+
+	if len(report.LargeObjects) > 0 {
+		heading("Largest packed objects")
+		for _, object := range report.LargeObjects {
+			label := fmt.Sprintf("  %s %s", object.ID[:12], object.Type)
+			ref := ""
+			if object.Path != "" {
+				label += " " + tview.Escape(object.Path)
+				ref = filepath.Join(report.Root, filepath.FromSlash(object.Path))
+			}
+			addRow(label, Utils.FormatSize(object.Size)+" unpacked", object.PackedSize, ref)
+		}
+	}
+
+	repoTable.ScrollToBeginning()
+	repoTable.Select(1, 0)
+}
@@ -7,7 +7,6 @@ import (
 	cache "DiskSizer/Cache"
 	"DiskSizer/Utils"
 
-	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
 
@@ -64,7 +63,7 @@ func addChildren(node *tview.TreeNode) {
 					return
 				case <-scanCancel:
 					app.QueueUpdateDraw(func() {
-						spinnerNode.SetText("[yellow]Scan cancelled")
+						spinnerNode.SetText(theme.Tag(theme.Warning) + "Scan cancelled")
 					})
 					return
 				default:
@@ -72,8 +71,9 @@ func addChildren(node *tview.TreeNode) {
 						elapsed := time.Since(startTime).Seconds()
 						ProcessedTime = elapsed
 						app.QueueUpdateDraw(func() {
-							spinnerText := fmt.Sprintf("%s[yellow] Scanning directory... [blue]Processed: %s [gray](%.1fs)",
-								symbols[i%len(symbols)], Utils.FormatSize(processedSize), elapsed)
+							spinnerText := fmt.Sprintf("%s%s Scanning directory... %sProcessed: %s %s(%.1fs)",
+								symbols[i%len(symbols)], theme.Tag(theme.Warning), theme.Tag(theme.Info),
+								Utils.FormatSize(processedSize), theme.Tag(theme.Muted), elapsed)
 							spinnerNode.SetText(spinnerText)
 						})
 					}
@@ -83,8 +83,9 @@ func addChildren(node *tview.TreeNode) {
 			}
 		}()
 
-		// First check if we have this in the cache
-		if cachedEntry, found := dirCache.Get(path); found {
+		// First check if we have this in the cache. An archive is cached as a
+		// file by the scan of its directory, until it has been opened.
+		if cachedEntry, found := dirCache.Lookup(path); found && cachedEntry.IsDir {
 			// Use the cached data instead of rescanning
 			spinnerActive = false
 			close(stopSpinner)
@@ -94,8 +95,8 @@ func addChildren(node *tview.TreeNode) {
 				node.RemoveChild(spinnerNode)
 
 				// Add a status node showing this is from cache
-				statusNode := tview.NewTreeNode(fmt.Sprintf("[green]From Cache: %s - %d items[/green]",
-					Utils.FormatSize(cachedEntry.Size), len(cachedEntry.Children))).SetSelectable(false).SetColor(tcell.ColorGreen)
+				statusNode := tview.NewTreeNode(fmt.Sprintf("From Cache: %s - %d items",
+					Utils.FormatSize(cachedEntry.Size), len(cachedEntry.Children))).SetSelectable(false).SetColor(theme.Cached.TextColor)
 				node.AddChild(statusNode)
 
 				// Convert the Cache.DirEntry to Utils.DirEntry
@@ -106,7 +107,7 @@ func addChildren(node *tview.TreeNode) {
 		}
 
 		// Perform the actual directory scan with cached method
-		dirEntry, skipped, err := cache.CachedScanDir(path, 1, 0, &processedSize, dirCache)
+		dirEntry, skipped, err := cachedScan(path)
 		spinnerActive = false
 		close(stopSpinner)
 
@@ -116,7 +117,7 @@ func addChildren(node *tview.TreeNode) {
 				// Remove the spinner node
 				node.RemoveChild(spinnerNode)
 				// Add cancelled node
-				cancelledNode := tview.NewTreeNode("[yellow]Scan cancelled").SetSelectable(false)
+				cancelledNode := tview.NewTreeNode("Scan cancelled").SetSelectable(false).SetColor(theme.Warning.TextColor)
 				node.AddChild(cancelledNode)
 			})
 			return
@@ -126,11 +127,11 @@ func addChildren(node *tview.TreeNode) {
 
 		if err != nil {
 			app.QueueUpdateDraw(func() {
-				statsView.SetText("[red]Error scanning path")
+				statsView.SetText(theme.Tag(theme.Error) + "Error scanning path")
 				// Remove spinner node
 				node.RemoveChild(spinnerNode)
 				// Add error node
-				errorNode := tview.NewTreeNode("[red]Error scanning directory").SetSelectable(false)
+				errorNode := tview.NewTreeNode("Error scanning directory").SetSelectable(false).SetColor(theme.Error.TextColor)
 				node.AddChild(errorNode)
 			})
 			return
@@ -141,8 +142,8 @@ func addChildren(node *tview.TreeNode) {
 			node.RemoveChild(spinnerNode)
 
 			// Add a status node at the top showing scan results
-			statusNode := tview.NewTreeNode(fmt.Sprintf("[blue]Scanned: %s - %d items (Skipped: %s) [gray](%.1fs)",
-				Utils.FormatSize(dirEntry.Size), len(dirEntry.Children), Utils.FormatSize(skipped), ProcessedTime)).SetSelectable(false).SetColor(tcell.ColorBlue)
+			statusNode := tview.NewTreeNode(fmt.Sprintf("Scanned: %s - %d items (Skipped: %s) %s(%.1fs)",
+				Utils.FormatSize(dirEntry.Size), len(dirEntry.Children), Utils.FormatSize(skipped), theme.Tag(theme.Muted), ProcessedTime)).SetSelectable(false).SetColor(theme.Info.TextColor)
 			node.AddChild(statusNode)
 
 			// Add directory entries to the node
@@ -158,10 +159,12 @@ func clearCache() {
 
 	// Clear the directory cache
 	dirCache.Clear()
+	closeArchives()
+	clearGitRepos()
 
 	// Update UI
 	app.QueueUpdateDraw(func() {
-		statsView.SetText("[yellow]Cache cleared. Press SPACE to refresh your view.")
+		statsView.SetText(theme.Tag(theme.Warning) + "Cache cleared. Press SPACE to refresh your view.")
 	})
 }
 
@@ -180,7 +183,7 @@ func cancelScan() {
 
 		isScanning = false
 		app.QueueUpdateDraw(func() {
-			statsView.SetText("[yellow]Scan cancelled. Press SPACE to restart scan.")
+			statsView.SetText(theme.Tag(theme.Warning) + "Scan cancelled. Press SPACE to restart scan.")
 		})
 	}
 }
@@ -192,12 +195,15 @@ func estimateCurrentDir() {
 		return
 	}
 
+	if !localOnly("Estimating") {
+		return
+	}
 	path := node.GetReference().(string)
 
 	// Start the estimation in the background
 	go func() {
 		app.QueueUpdateDraw(func() {
-			statsView.SetText("[yellow]Estimating directory size...")
+			statsView.SetText(theme.Tag(theme.Warning) + "Estimating directory size...")
 		})
 
 		// Get a quick estimate using sampling
@@ -205,10 +211,10 @@ func estimateCurrentDir() {
 
 		app.QueueUpdateDraw(func() {
 			if err != nil {
-				statsView.SetText("[red]Error estimating directory size")
+				statsView.SetText(theme.Tag(theme.Error) + "Error estimating directory size")
 			} else {
-				statsView.SetText(fmt.Sprintf("[green]Estimated Size: %s[white]\n(This is an approximate value based on sampling)",
-					Utils.FormatSize(size)))
+				statsView.SetText(fmt.Sprintf("%sEstimated Size: %s[-:-:-]\n(This is an approximate value based on sampling)",
+					theme.Tag(theme.Success), Utils.FormatSize(size)))
 			}
 		})
 	}()
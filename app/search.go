@@ -0,0 +1,269 @@
+package app
+
+import (
+	cache "DiskSizer/Cache"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Search state
+var (
+	searchQuery    string
+	searchHits     []string
+	searchIndex    int
+	searchFiltered bool // The tree only shows the matches and their parents
+	highlighted    = make(map[*tview.TreeNode]tcell.Style)
+)
+
+// startSearch opens the "/" prompt, highlights the matches as the user types
+// and shows only the matches once the search is entered
+func startSearch() {
+	setSearchFilter(false)
+	showPrompt("/", searchQuery, func(text string) {
+		runSearch(text)
+	}, func(text string, ok bool) {
+		if !ok {
+			clearSearch()
+			return
+		}
+		runSearch(text)
+		setSearchFilter(searchQuery != "")
+		jumpToHit(0)
+	})
+}
+
+// setSearchFilter limits the tree to the matches of the search and the
+// directories leading to them, or shows everything again
+func setSearchFilter(filtered bool) {
+	if searchFiltered == filtered {
+		return
+	}
+	searchFiltered = filtered
+	rebuildTree()
+}
+
+// searchMatches reports whether a name contains the search query
+// (case-insensitive)
+func searchMatches(name string) bool {
+	return searchQuery != "" && strings.Contains(strings.ToLower(name), strings.ToLower(searchQuery))
+}
+
+// searchMatchedAbove reports whether path or one of its parents below the
+// tree root matches the search, so that everything below it is shown
+func searchMatchedAbove(path string) bool {
+	rootPath := treeView.GetRoot().GetReference().(string)
+	for path != rootPath && path != filepath.Dir(path) {
+		if searchMatches(filepath.Base(path)) {
+			return true
+		}
+		path = filepath.Dir(path)
+	}
+	return false
+}
+
+// collectSearchHits appends the paths of the entries below entry whose name
+// contains lowerQuery to searchHits. It walks the cached tree itself, as
+// copying it for every key typed would be slow on large scans.
+func collectSearchHits(entry cache.DirEntry, lowerQuery string) {
+	for _, child := range entry.Children {
+		if strings.Contains(strings.ToLower(child.Name), lowerQuery) {
+			searchHits = append(searchHits, child.Path)
+		}
+		collectSearchHits(child, lowerQuery)
+	}
+}
+
+// runSearch collects all scanned entries below the tree root whose name
+// contains query (case-insensitive) and highlights the visible ones
+func runSearch(query string) {
+	searchQuery = query
+	searchHits = nil
+	searchIndex = 0
+
+	if query == "" {
+		applySearchHighlight()
+		return
+	}
+
+	rootPath := treeView.GetRoot().GetReference().(string)
+	cachedEntry, found := dirCache.Lookup(rootPath)
+	if !found {
+		statsView.SetText(theme.Tag(theme.Warning) + "Nothing scanned yet, wait for the scan to finish and search again")
+		return
+	}
+
+	collectSearchHits(cachedEntry, strings.ToLower(query))
+
+	applySearchHighlight()
+	statsView.SetText(fmt.Sprintf(theme.Tag(theme.Warning)+"Search [-:-:-]%s"+theme.Tag(theme.Warning)+": %d matches "+theme.Tag(theme.Muted)+"(n: next, N: previous)",
+		tview.Escape(fmt.Sprintf("%q", query)), len(searchHits)))
+}
+
+// jumpToHit reveals the search hit at index i, wrapping around both ends
+func jumpToHit(i int) {
+	if len(searchHits) == 0 {
+		return
+	}
+
+	searchIndex = (i%len(searchHits) + len(searchHits)) % len(searchHits)
+	hit := searchHits[searchIndex]
+	if revealPath(hit) == nil {
+		statsView.SetText(fmt.Sprintf(theme.Tag(theme.Error)+"Could not reveal %s", tview.Escape(hit)))
+		return
+	}
+
+	statsView.SetText(fmt.Sprintf(theme.Tag(theme.Warning)+"Match %d/%d: [-:-:-]%s", searchIndex+1, len(searchHits), tview.Escape(hit)))
+}
+
+// clearSearch drops the current search, its highlights and its filter
+func clearSearch() {
+	searchQuery = ""
+	searchHits = nil
+	searchIndex = 0
+	setSearchFilter(false)
+	applySearchHighlight()
+	updateStats()
+}
+
+// applySearchHighlight highlights every tree node matching the current query
+// and restores the style of nodes that no longer match
+func applySearchHighlight() {
+	treeView.GetRoot().Walk(func(node, parent *tview.TreeNode) bool {
+		ref := node.GetReference()
+		if ref == nil || parent == nil {
+			return true
+		}
+
+		matches := searchMatches(filepath.Base(ref.(string)))
+		original, isHighlighted := highlighted[node]
+
+		switch {
+		case matches && !isHighlighted:
+			highlighted[node] = node.GetTextStyle()
+			style := node.GetTextStyle().Bold(theme.Highlight.Bold).Underline(theme.Highlight.Underline)
+			if theme.Highlight.BackgroundColor != tcell.ColorDefault {
+				style = style.Background(theme.Highlight.BackgroundColor)
+			}
+			node.SetTextStyle(style)
+		case !matches && isHighlighted:
+			node.SetTextStyle(original)
+			delete(highlighted, node)
+		}
+		return true
+	})
+}
+
+// revealPath expands every ancestor of target below the tree root, loading
+// children from the cache where needed, and selects the target node
+func revealPath(target string) *tview.TreeNode {
+	root := treeView.GetRoot()
+	rootPath := root.GetReference().(string)
+
+	rel, err := filepath.Rel(rootPath, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil
+	}
+
+	node := root
+	current := rootPath
+	if rel != "." {
+		for _, name := range strings.Split(rel, string(filepath.Separator)) {
+			ensureChildrenLoaded(node)
+			node.SetExpanded(true)
+
+			current = filepath.Join(current, name)
+			node = findNodeByPath(node, current)
+			if node == nil {
+				return nil
+			}
+		}
+	}
+
+	treeView.SetCurrentNode(node)
+	CurrentPath = current
+	applySearchHighlight()
+	return node
+}
+
+// ensureChildrenLoaded fills an empty node synchronously from the cache
+func ensureChildrenLoaded(node *tview.TreeNode) {
+	if len(node.GetChildren()) > 0 {
+		return
+	}
+
+	path := node.GetReference().(string)
+	if cachedEntry, found := dirCache.Lookup(path); found {
+		addDirEntryToNode(node, cache.ToUtilsDirEntry(cachedEntry), path)
+	}
+}
+
+// startGoToPath opens the "go to path" prompt
+func startGoToPath() {
+	showPrompt("Go to: ", CurrentPath, nil, func(text string, ok bool) {
+		if ok && strings.TrimSpace(text) != "" {
+			goToPath(strings.TrimSpace(text))
+		}
+	})
+}
+
+// goToPath scans whatever is needed to show target and reveals it. Paths
+// outside the current tree root re-root the tree at the target directory.
+func goToPath(target string) {
+	if strings.HasPrefix(target, "~") && isLocal() {
+		if home, err := os.UserHomeDir(); err == nil {
+			target = filepath.Join(home, target[1:])
+		}
+	}
+
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(CurrentPath, target)
+	}
+	target = filepath.Clean(target)
+
+	info, err := fsys.Stat(target)
+	if err != nil {
+		statsView.SetText(fmt.Sprintf(theme.Tag(theme.Error)+"Cannot open %s: %v", tview.Escape(target), err))
+		return
+	}
+
+	rootPath := treeView.GetRoot().GetReference().(string)
+	scanPath := rootPath
+	rel, err := filepath.Rel(rootPath, target)
+	outside := err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator))
+	if outside {
+		scanPath = target
+		if !info.IsDir {
+			scanPath = filepath.Dir(target)
+		}
+	}
+
+	statsView.SetText(fmt.Sprintf(theme.Tag(theme.Warning)+"Scanning %s...", tview.Escape(scanPath)))
+
+	go func() {
+		if _, found := dirCache.Lookup(target); !found {
+			if _, _, err := cachedScan(scanPath); err != nil {
+				app.QueueUpdateDraw(func() {
+					statsView.SetText(fmt.Sprintf(theme.Tag(theme.Error)+"Error scanning %s: %v", tview.Escape(scanPath), err))
+				})
+				return
+			}
+		}
+
+		app.QueueUpdateDraw(func() {
+			if outside {
+				root := setTreeRoot(scanPath)
+				ensureChildrenLoaded(root)
+			}
+			if revealPath(target) == nil {
+				statsView.SetText(fmt.Sprintf(theme.Tag(theme.Error)+"Could not reveal %s", tview.Escape(target)))
+				return
+			}
+			updateStats()
+		})
+	}()
+}
@@ -0,0 +1,304 @@
+package app
+
+import (
+	cache "DiskSizer/Cache"
+	"DiskSizer/Utils"
+	"DiskSizer/styling"
+	"fmt"
+	"math"
+	"path/filepath"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// treemapCell is the screen area of one child in the treemap
+type treemapCell struct {
+	x0, y0, x1, y1 int
+}
+
+// treemapView draws the children of a directory as a squarified treemap
+type treemapView struct {
+	*tview.Box
+	entry   Utils.DirEntry
+	cells   []treemapCell
+	focus   int
+	message string
+}
+
+// newTreemapView creates an empty treemap view
+func newTreemapView() *treemapView {
+	return &treemapView{
+		Box: tview.NewBox(),
+	}
+}
+
+// setEntry shows the children of entry and focuses the largest one
+func (t *treemapView) setEntry(entry Utils.DirEntry) {
+	t.entry = entry
+	t.cells = nil
+	t.focus = 0
+	t.message = ""
+	CurrentPath = entry.Path
+}
+
+// Draw renders the treemap with a header line and a status line
+func (t *treemapView) Draw(screen tcell.Screen) {
+	t.Box.DrawForSubclass(screen, t)
+	x, y, width, height := t.GetInnerRect()
+	if width <= 0 || height < 3 {
+		return
+	}
+
+	header := fmt.Sprintf("[::b]%s[::-] "+theme.Tag(theme.Muted)+"(%s, %d items)",
+		tview.Escape(t.entry.Path), Utils.FormatSize(t.entry.Size), len(t.entry.Children))
+	tview.Print(screen, header, x, y, width, tview.AlignLeft, theme.Text)
+
+	mapY, mapHeight := y+1, height-2
+	if len(t.entry.Children) == 0 {
+		tview.Print(screen, "Nothing to show in this directory", x, mapY+mapHeight/2, width, tview.AlignCenter, theme.Muted.TextColor)
+		t.cells = nil
+	} else {
+		t.layout(x, mapY, width, mapHeight)
+		for i := range t.entry.Children {
+			t.drawCell(screen, i)
+		}
+	}
+
+	tview.Print(screen, t.statusText(), x, y+height-1, width, tview.AlignLeft, theme.Text)
+}
+
+// layout computes the screen cells of all children
+func (t *treemapView) layout(x, y, width, height int) {
+	values := make([]float64, len(t.entry.Children))
+	for i, child := range t.entry.Children {
+		values[i] = float64(child.Size)
+	}
+
+	// Terminal cells are about twice as tall as they are wide, so lay out in
+	// a space with doubled height to get visually square rectangles
+	rects := Utils.Squarify(values, 0, 0, float64(width), float64(height)*2)
+
+	t.cells = make([]treemapCell, len(rects))
+	for i, r := range rects {
+		t.cells[i] = treemapCell{
+			x0: x + int(math.Round(r.X)),
+			y0: y + int(math.Round(r.Y/2)),
+			x1: x + int(math.Round(r.X+r.W)),
+			y1: y + int(math.Round((r.Y+r.H)/2)),
+		}
+	}
+}
+
+// drawCell fills the rectangle of child i and prints its label
+func (t *treemapView) drawCell(screen tcell.Screen, i int) {
+	cell := t.cells[i]
+	if cell.x1 <= cell.x0 || cell.y1 <= cell.y0 {
+		return
+	}
+
+	child := t.entry.Children[i]
+	fill := tcell.StyleDefault.Background(t.cellColor(i))
+
+	// Leave the last row and column empty to separate neighbours
+	innerX1, innerY1 := cell.x1, cell.y1
+	if cell.x1-cell.x0 > 1 {
+		innerX1--
+	}
+	if cell.y1-cell.y0 > 1 {
+		innerY1--
+	}
+
+	for yy := cell.y0; yy < cell.y1; yy++ {
+		for xx := cell.x0; xx < cell.x1; xx++ {
+			style := tcell.StyleDefault
+			if xx < innerX1 && yy < innerY1 {
+				style = fill
+			}
+			screen.SetContent(xx, yy, ' ', nil, style)
+		}
+	}
+
+	labelWidth := innerX1 - cell.x0
+	label := fmt.Sprintf("%s %s", Utils.GetFileIcon(child.Name, child.IsDir), tview.Escape(child.Name))
+	if i == t.focus {
+		label = "[::b]" + label
+	}
+	tview.Print(screen, label, cell.x0, cell.y0, labelWidth, tview.AlignLeft, tcell.ColorBlack)
+	if innerY1-cell.y0 > 1 {
+		tview.Print(screen, Utils.FormatSize(child.Size), cell.x0, cell.y0+1, labelWidth, tview.AlignLeft, tcell.ColorBlack)
+	}
+}
+
+// cellColor picks the colour of child i from its share of the directory,
+// dimmed for files and lightened when focused
+func (t *treemapView) cellColor(i int) tcell.Color {
+	child := t.entry.Children[i]
+	color := theme.SizeColor(child.Size, t.entry.Size)
+
+	r, g, b := color.RGB()
+	factor := 1.0
+	if !child.IsDir {
+		factor = 0.65
+	}
+	if i == t.focus {
+		// Blend half way towards white
+		return tcell.NewRGBColor(int32(float64(r)*factor+255)/2, int32(float64(g)*factor+255)/2, int32(float64(b)*factor+255)/2)
+	}
+	return tcell.NewRGBColor(int32(float64(r)*factor), int32(float64(g)*factor), int32(float64(b)*factor))
+}
+
+// statusText describes the focused child
+func (t *treemapView) statusText() string {
+	keys := theme.Tag(theme.Muted) + tview.Escape(keyHints("ENTER: Open", "BACKSPACE: Up", keyHint(actionTreemap, "Tree View")))
+	if t.message != "" {
+		return t.message + "  " + keys
+	}
+	if t.focus >= len(t.entry.Children) {
+		return keys
+	}
+
+	child := t.entry.Children[t.focus]
+	percent := 0.0
+	if t.entry.Size > 0 {
+		percent = float64(child.Size) / float64(t.entry.Size) * 100
+	}
+	sizeStyle := styling.NewStyleBuilder().WithTextColor(theme.SizeColor(child.Size, t.entry.Size)).Build()
+	return fmt.Sprintf("%s▶ [-:-:-]%s %s%s (%.1f%%)  %s", theme.Tag(theme.Warning),
+		tview.Escape(child.Name), theme.Tag(sizeStyle), Utils.FormatSize(child.Size), percent, keys)
+}
+
+// moveFocus focuses the nearest child in the direction dx, dy
+func (t *treemapView) moveFocus(dx, dy int) {
+	if t.focus >= len(t.cells) {
+		return
+	}
+
+	center := func(c treemapCell) (float64, float64) {
+		return float64(c.x0+c.x1) / 2, float64(c.y0+c.y1) / 2
+	}
+	cx, cy := center(t.cells[t.focus])
+
+	best, bestDistance := -1, math.Inf(1)
+	for i, cell := range t.cells {
+		if i == t.focus || cell.x1 <= cell.x0 || cell.y1 <= cell.y0 {
+			continue
+		}
+
+		ox, oy := center(cell)
+		vx, vy := ox-cx, oy-cy
+		along, across := vx*float64(dx)+vy*float64(dy), math.Abs(vx*float64(dy))+math.Abs(vy*float64(dx))
+		if along <= 0 {
+			continue
+		}
+
+		if distance := along + 2*across; distance < bestDistance {
+			best, bestDistance = i, distance
+		}
+	}
+
+	if best >= 0 {
+		t.focus = best
+	}
+}
+
+// drillDown shows the children of the focused directory
+func (t *treemapView) drillDown() {
+	if t.focus >= len(t.entry.Children) {
+		return
+	}
+
+	child := t.entry.Children[t.focus]
+	if !child.IsDir {
+		t.message = theme.Tag(theme.Warning) + "Not a directory"
+		return
+	}
+	t.setEntry(child)
+}
+
+// goUp shows the parent directory if it has been scanned
+func (t *treemapView) goUp() {
+	previous := t.entry.Path
+	parentPath := filepath.Dir(previous)
+	if parentPath == previous {
+		return
+	}
+
+	cachedEntry, found := dirCache.Lookup(parentPath)
+	if !found {
+		t.message = theme.Tag(theme.Warning) + "Parent directory has not been scanned"
+		return
+	}
+
+	t.setEntry(cache.ToUtilsDirEntry(cachedEntry))
+	for i, child := range t.entry.Children {
+		if child.Path == previous {
+			t.focus = i
+			break
+		}
+	}
+}
+
+// InputHandler handles navigation inside the treemap
+func (t *treemapView) InputHandler() func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+	return t.WrapInputHandler(func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+		t.message = ""
+		switch event.Key() {
+		case tcell.KeyLeft:
+			t.moveFocus(-1, 0)
+		case tcell.KeyRight:
+			t.moveFocus(1, 0)
+		case tcell.KeyUp:
+			t.moveFocus(0, -1)
+		case tcell.KeyDown:
+			t.moveFocus(0, 1)
+		case tcell.KeyEnter:
+			t.drillDown()
+		case tcell.KeyBackspace, tcell.KeyBackspace2:
+			t.goUp()
+		}
+	})
+}
+
+// MouseHandler focuses a rectangle on click and opens it on double click
+func (t *treemapView) MouseHandler() func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+	return t.WrapMouseHandler(func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+		x, y := event.Position()
+		if !t.InRect(x, y) {
+			return false, nil
+		}
+
+		switch action {
+		case tview.MouseLeftClick, tview.MouseLeftDoubleClick:
+			setFocus(t)
+			for i, cell := range t.cells {
+				if x >= cell.x0 && x < cell.x1 && y >= cell.y0 && y < cell.y1 {
+					t.focus = i
+					if action == tview.MouseLeftDoubleClick {
+						t.drillDown()
+					}
+					break
+				}
+			}
+			return true, nil
+		}
+		return false, nil
+	})
+}
+
+// toggleTreemap switches between the tree and the treemap of the current
+// directory
+func toggleTreemap() {
+	if paneShown() {
+		closePane()
+		return
+	}
+
+	entry, found := currentDirEntry()
+	if !found {
+		return
+	}
+
+	treemap.setEntry(entry)
+	showPane("treemap", treemap)
+}
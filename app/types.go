@@ -0,0 +1,79 @@
+package app
+
+import (
+	"DiskSizer/Utils"
+	"fmt"
+
+	"github.com/rivo/tview"
+)
+
+// File type pane state
+var (
+	typesTable      *tview.Table
+	typesEntry      Utils.DirEntry
+	typesByCategory = true
+	typesSort       = Utils.SortBySize
+)
+
+// showTypesPane shows the file type breakdown of the current directory
+func showTypesPane() {
+	entry, found := currentDirEntry()
+	if !found {
+		return
+	}
+	typesEntry = entry
+
+	if typesTable == nil {
+		typesTable = newUsageTable()
+
+		paneHandlers["types"] = map[string]func(){
+			actionSwitchView: func() {
+				typesByCategory = !typesByCategory
+				renderTypesTable()
+			},
+			actionChangeSort: func() {
+				typesSort = nextSortOrder(typesSort)
+				renderTypesTable()
+			},
+		}
+	}
+
+	renderTypesTable()
+	showPane("types", typesTable)
+}
+
+// nextSortOrder cycles through the sort orders of breakdown panes
+func nextSortOrder(current string) string {
+	switch current {
+	case Utils.SortBySize:
+		return Utils.SortByCount
+	case Utils.SortByCount:
+		return Utils.SortByName
+	default:
+		return Utils.SortBySize
+	}
+}
+
+// renderTypesTable fills the file type table for typesEntry
+func renderTypesTable() {
+	byExtension, byCategory := Utils.TypeBreakdown(typesEntry)
+	stats, keyTitle := byExtension, "Extension"
+	if typesByCategory {
+		stats, keyTitle = byCategory, "Category"
+	}
+	Utils.SortUsageStats(stats, typesSort)
+
+	typesTable.SetTitle(fmt.Sprintf(" File types in %s "+theme.Tag(theme.Muted)+"(%s) ",
+		tview.Escape(typesEntry.Path), tview.Escape(keyHints(
+			keyHint(actionSwitchView, "Category/Extension"),
+			keyHint(actionChangeSort, fmt.Sprintf("Sort by %s", typesSort)),
+			keyHint(actionFileTypes, "Close")))))
+
+	var label func(key string) string
+	if typesByCategory {
+		label = func(key string) string {
+			return Utils.GetCategoryIcon(key) + " " + key
+		}
+	}
+	renderUsageTable(typesTable, keyTitle, stats, typesEntry.Size, label)
+}
@@ -1,51 +1,210 @@
 package app
 
 import (
+	cache "DiskSizer/Cache"
 	"DiskSizer/Utils"
+	"DiskSizer/agent"
+	"DiskSizer/styling"
 	"fmt"
 	"path/filepath"
-	"sort"
 
-	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
 	"github.com/rivo/tview"
 )
 
 // updateStats updates the disk stats view with interactive elements
 func updateStats() {
-	// Use the interactive stats
-	statsText := Utils.GetDiskStatsInteractive(statsView, app)
-	statsView.SetText(statsText)
+	switch source := fsys.(type) {
+	case *agent.Client:
+		statsView.SetText(agentStats(source))
+	case *snapshotFileSystem:
+		statsView.SetText(source.stats())
+	default:
+		// Use the interactive stats
+		statsView.SetText(Utils.GetDiskStatsInteractive(statsView, app))
+	}
 }
 
 // addDirEntryToNode adds a directory entry to a tree node
 func addDirEntryToNode(node *tview.TreeNode, dirEntry Utils.DirEntry, path string) {
-	// Sort children by size (larger files first)
-	sort.Slice(dirEntry.Children, func(i, j int) bool {
-		return dirEntry.Children[i].Size > dirEntry.Children[j].Size
-	})
+	if treeFilter != nil {
+		dirEntry = Utils.FilterEntries(dirEntry, treeFilter)
+	}
+	if searchFiltered && !searchMatchedAbove(path) {
+		dirEntry = Utils.FilterMatches(dirEntry, func(e Utils.DirEntry) bool {
+			return searchMatches(e.Name)
+		})
+	}
+
+	dirEntry.Children = sortedEntries(dirEntry.Children)
+
+	names := make([]string, len(dirEntry.Children))
+	for i, child := range dirEntry.Children {
+		names[i] = labelName(child.Name, child.IsDir)
+	}
+	nameWidth := nameColumnWidth(names)
+	archived := inArchive(path)
 
 	// Add all the directory entries
-	for _, child := range dirEntry.Children {
-		isDir := len(child.Children) > 0
+	for i, child := range dirEntry.Children {
+		isDir := child.IsDir
 		childPath := filepath.Join(path, child.Name)
 
-		var childNode *tview.TreeNode
+		childNode := tview.NewTreeNode(treeLabel(names[i], child, childPath, dirEntry.Size, nameWidth, archived)).
+			SetReference(childPath).
+			SetSelectable(true)
 		if isDir {
-			childNode = tview.NewTreeNode(fmt.Sprintf("%s %s (%s)",
-				Utils.GetFileIcon(child.Name, true),
-				child.Name,
-				Utils.FormatSize(child.Size))).SetReference(childPath).SetSelectable(true).SetColor(tcell.ColorGreen)
+			childNode.SetColor(theme.Directory.TextColor)
+		} else if !archived && isLocal() && Utils.IsArchive(child.Name) {
+			// Archives open like directories
+			childNode.SetColor(theme.Archive.TextColor)
 		} else {
-			childNode = tview.NewTreeNode(fmt.Sprintf("%s %s (%s)",
-				Utils.GetFileIcon(child.Name, false),
-				child.Name,
-				Utils.FormatSize(child.Size))).SetReference(childPath).SetSelectable(true).SetColor(tcell.ColorWhite)
+			childNode.SetColor(theme.File.TextColor)
 		}
 
 		node.AddChild(childNode)
 	}
 }
 
+// Size bar column settings
+const (
+	sizeBarWidth       = 20
+	maxNameColumnWidth = 48
+)
+
+// showSizeBars adds a bar with each entry's share of its parent to the labels
+var showSizeBars = false
+
+// labelName returns the icon and name shown for an entry
+func labelName(name string, isDir bool) string {
+	return Utils.GetFileIcon(name, isDir) + " " + name
+}
+
+// nameColumnWidth returns the width in cells needed to align the given names
+func nameColumnWidth(names []string) int {
+	width := 0
+	for _, name := range names {
+		width = max(width, runewidth.StringWidth(name))
+	}
+	return min(width, maxNameColumnWidth)
+}
+
+// entryLabel builds the tree label for an entry. With size bars enabled the
+// name is padded to nameWidth cells so the bars of siblings line up.
+func entryLabel(name string, size, parentSize int64, nameWidth int) string {
+	if !showSizeBars {
+		return fmt.Sprintf("%s (%s)", tview.Escape(name), Utils.FormatSize(size))
+	}
+
+	name = runewidth.Truncate(name, nameWidth, "…")
+	name = runewidth.FillRight(name, nameWidth)
+
+	ratio := 0.0
+	if parentSize > 0 {
+		ratio = float64(size) / float64(parentSize)
+	}
+	barColor := theme.SizeColor(size, parentSize)
+
+	return fmt.Sprintf("%s %10s %s", tview.Escape(name), Utils.FormatSize(size),
+		styling.CreateSizeBar(ratio, sizeBarWidth, barColor))
+}
+
+// treeLabel builds the label of a tree node: the entry label followed by the
+// compressed size of archive entries or the annotations of other entries
+func treeLabel(name string, entry Utils.DirEntry, path string, parentSize int64, nameWidth int, archived bool) string {
+	label := entryLabel(name, entry.Size, parentSize, nameWidth)
+	if archived {
+		return label + compressedLabel(entry)
+	}
+	return label + annotations(entry, path)
+}
+
+// toggleSizeBars shows or hides the size bar column and relabels the tree
+func toggleSizeBars() {
+	showSizeBars = !showSizeBars
+
+	treeView.GetRoot().Walk(func(node, parent *tview.TreeNode) bool {
+		ref := node.GetReference()
+		if ref == nil || len(node.GetChildren()) == 0 {
+			return true
+		}
+
+		path := ref.(string)
+		entry, found := treeEntry(path)
+		if !found {
+			return true
+		}
+
+		names := make(map[string]string, len(entry.Children))
+		children := make(map[string]Utils.DirEntry, len(entry.Children))
+		var columnNames []string
+		for _, child := range entry.Children {
+			childPath := filepath.Join(path, child.Name)
+			names[childPath] = labelName(child.Name, child.IsDir)
+			children[childPath] = child
+			columnNames = append(columnNames, names[childPath])
+		}
+		nameWidth := nameColumnWidth(columnNames)
+		archived := inArchive(path)
+
+		for _, childNode := range node.GetChildren() {
+			childRef := childNode.GetReference()
+			if childRef == nil {
+				continue
+			}
+			if name, ok := names[childRef.(string)]; ok {
+				childNode.SetText(treeLabel(name, children[childRef.(string)], childRef.(string), entry.Size, nameWidth, archived))
+			}
+		}
+		return true
+	})
+}
+
+// Tree filter state
+var (
+	treeFilter      func(e Utils.DirEntry) bool
+	treeFilterLabel string
+)
+
+// treeEntry returns the cached entry for path with the tree filter applied
+func treeEntry(path string) (Utils.DirEntry, bool) {
+	cachedEntry, found := dirCache.Lookup(path)
+	if !found {
+		return Utils.DirEntry{}, false
+	}
+
+	entry := cache.ToUtilsDirEntry(cachedEntry)
+	if treeFilter != nil {
+		entry = Utils.FilterEntries(entry, treeFilter)
+	}
+	return entry, true
+}
+
+// setTreeFilter limits the tree to the files for which keep returns true
+func setTreeFilter(label string, keep func(e Utils.DirEntry) bool) {
+	treeFilter = keep
+	treeFilterLabel = label
+	rebuildTree()
+}
+
+// clearTreeFilter shows all files in the tree again
+func clearTreeFilter() {
+	if treeFilter == nil {
+		return
+	}
+	setTreeFilter("", nil)
+}
+
+// rebuildTree recreates the tree from the cache and reveals the current path
+func rebuildTree() {
+	rootPath := treeView.GetRoot().GetReference().(string)
+	current := CurrentPath
+
+	root := setTreeRoot(rootPath)
+	ensureChildrenLoaded(root)
+	revealPath(current)
+}
+
 // findNodeByPath finds a tree node by path
 func findNodeByPath(node *tview.TreeNode, targetPath string) *tview.TreeNode {
 	ref := node.GetReference()
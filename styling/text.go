@@ -2,6 +2,7 @@ package styling
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 	"sync"
 
@@ -32,7 +33,7 @@ func DefaultStyleOptions() StyleOptions {
 		Italic:          false,
 		Underline:       false,
 		Blink:           false,
-		TextColor:       tcell.ColorWhite,
+		TextColor:       tcell.ColorDefault,
 		BackgroundColor: tcell.ColorDefault,
 	}
 }
@@ -134,40 +135,40 @@ var GlobalRegistry = NewActionRegistry()
 
 // ApplyStyle applies styling to text using tview's color tags
 func ApplyStyle(text string, style StyleOptions) string {
-	// Start with opening color tag
-	result := "["
+	tag := styleTag(style)
+	if tag == "" {
+		return text
+	}
+	return tag + text + "[-:-:-]"
+}
 
-	// Add text color
+// styleTag returns the tview tag [foreground:background:attributes] of
+// style, or "" if it changes nothing
+func styleTag(style StyleOptions) string {
+	var foreground, background, attributes string
 	if style.TextColor != tcell.ColorDefault {
-		result += fmt.Sprintf("#%06x", style.TextColor.Hex())
+		foreground = fmt.Sprintf("#%06x", style.TextColor.Hex())
 	}
-
-	// Add background color if specified
 	if style.BackgroundColor != tcell.ColorDefault {
-		result += ":" + fmt.Sprintf("#%06x", style.BackgroundColor.Hex())
+		background = fmt.Sprintf("#%06x", style.BackgroundColor.Hex())
 	}
-
-	// Add style attributes
 	if style.Bold {
-		result += ":b"
+		attributes += "b"
 	}
 	if style.Italic {
-		result += ":i"
+		attributes += "i"
 	}
 	if style.Underline {
-		result += ":u"
+		attributes += "u"
 	}
 	if style.Blink {
-		result += ":l" // 'l' for blink in tview
+		attributes += "l"
 	}
 
-	// Close the opening tag and add the text
-	result += "]" + text
-
-	// Add closing tag
-	result += "[-]"
-
-	return result
+	if foreground == "" && background == "" && attributes == "" {
+		return ""
+	}
+	return "[" + foreground + ":" + background + ":" + attributes + "]"
 }
 
 // MakeClickable creates clickable text that executes a callback when selected
@@ -177,12 +178,34 @@ func MakeClickable(text string, style StyleOptions, callback func()) string {
 	return fmt.Sprintf(`["%s"]%s[""]`, actionID, styledText)
 }
 
-// InstallClickHandler installs the handler for clickable text in a TextView
+// InstallClickHandler installs the handler for clickable text in a TextView.
+// Clicking a region runs its action right away; regions highlighted from the
+// keyboard (see SelectRegion) run when Enter is pressed.
 func InstallClickHandler(textView *tview.TextView, app *tview.Application) {
 	textView.SetRegions(true) // Enable region support
 
-	// Highlight region when mouse clicks
+	// Remember whether the next highlight change comes from a mouse click
+	clicked := false
+	textView.SetMouseCapture(func(action tview.MouseAction, event *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
+		clicked = action == tview.MouseLeftClick
+		return action, event
+	})
+
+	textView.SetHighlightedFunc(func(added, removed, remaining []string) {
+		if !clicked {
+			return
+		}
+		clicked = false
+		for _, regionID := range added {
+			GlobalRegistry.Execute(regionID)
+		}
+	})
+
+	// Run the highlighted region's action on Enter
 	textView.SetDoneFunc(func(key tcell.Key) {
+		if key != tcell.KeyEnter {
+			return
+		}
 		regions := textView.GetHighlights()
 		if len(regions) > 0 {
 			regionID := regions[0]
@@ -193,12 +216,48 @@ func InstallClickHandler(textView *tview.TextView, app *tview.Application) {
 	})
 }
 
+// regionPattern matches the region tags written by MakeClickable
+var regionPattern = regexp.MustCompile(`\["(action-[0-9]+)"\]`)
+
+// RegionIDs returns the IDs of all clickable regions in text, in order
+func RegionIDs(text string) []string {
+	var ids []string
+	for _, match := range regionPattern.FindAllStringSubmatch(text, -1) {
+		ids = append(ids, match[1])
+	}
+	return ids
+}
+
+// SelectRegion moves the highlight in textView by offset clickable regions,
+// wrapping around at both ends, and scrolls it into view. An offset of 0
+// highlights the first region if none is highlighted yet.
+func SelectRegion(textView *tview.TextView, offset int) {
+	ids := RegionIDs(textView.GetText(false))
+	if len(ids) == 0 {
+		return
+	}
+
+	current := -1
+	if highlights := textView.GetHighlights(); len(highlights) > 0 {
+		for i, id := range ids {
+			if id == highlights[0] {
+				current = i
+				break
+			}
+		}
+	}
+
+	next := 0
+	if current >= 0 {
+		next = ((current+offset)%len(ids) + len(ids)) % len(ids)
+	}
+
+	textView.Highlight(ids[next]).ScrollToHighlight()
+}
+
 // CreateInfoText creates styled informational text
 func CreateInfoText(label, value string, valueColor tcell.Color) string {
-	labelStyle := NewStyleBuilder().
-		WithBold().
-		WithTextColor(tcell.ColorWhite).
-		Build()
+	labelStyle := Current.Label
 
 	valueStyle := NewStyleBuilder().
 		WithTextColor(valueColor).
@@ -216,11 +275,11 @@ func FormatSizeWithColor(size float64, total float64, unit string) string {
 
 	var color tcell.Color
 	if percentUsed > 90 {
-		color = tcell.ColorRed
+		color = Current.Levels[3]
 	} else if percentUsed > 70 {
-		color = tcell.ColorYellow
+		color = Current.Levels[1]
 	} else {
-		color = tcell.ColorGreen
+		color = Current.Levels[0]
 	}
 
 	style := NewStyleBuilder().
@@ -246,11 +305,11 @@ func CreateProgressBar(used float64, total float64, width int) string {
 	// Choose color based on usage percentage
 	var barColor tcell.Color
 	if percentage > 0.9 {
-		barColor = tcell.ColorRed
+		barColor = Current.Levels[3]
 	} else if percentage > 0.7 {
-		barColor = tcell.ColorYellow
+		barColor = Current.Levels[1]
 	} else {
-		barColor = tcell.ColorGreen
+		barColor = Current.Levels[0]
 	}
 
 	// Create the filled part
@@ -265,7 +324,7 @@ func CreateProgressBar(used float64, total float64, width int) string {
 
 	// Create the empty part
 	emptyStyle := NewStyleBuilder().
-		WithTextColor(tcell.ColorWhite).
+		WithTextColor(Current.Text).
 		Build()
 	empty := strings.Repeat("░", width-filledWidth)
 	styledEmpty := ApplyStyle(empty, emptyStyle)
@@ -273,6 +332,29 @@ func CreateProgressBar(used float64, total float64, width int) string {
 	return styledFilled + styledEmpty
 }
 
+// CreateSizeBar generates a compact bar showing ratio (0 to 1) in the given
+// color, followed by the percentage
+func CreateSizeBar(ratio float64, width int, color tcell.Color) string {
+	if ratio < 0 {
+		ratio = 0
+	} else if ratio > 1 {
+		ratio = 1
+	}
+
+	filledWidth := int(float64(width)*ratio + 0.5)
+
+	filledStyle := NewStyleBuilder().
+		WithTextColor(color).
+		Build()
+	emptyStyle := Current.Muted
+
+	filled := ApplyStyle(strings.Repeat("█", filledWidth), filledStyle)
+	empty := ApplyStyle(strings.Repeat("░", width-filledWidth), emptyStyle)
+	percentText := ApplyStyle(fmt.Sprintf("%5.1f%%", ratio*100), filledStyle)
+
+	return filled + empty + " " + percentText
+}
+
 // SplitIntoPages splits long text into pages with given height
 func SplitIntoPages(text string, linesPerPage int) []string {
 	lines := strings.Split(text, "\n")
@@ -293,10 +375,7 @@ func SplitIntoPages(text string, linesPerPage int) []string {
 
 // CreateHeader creates a styled section header
 func CreateHeader(text string) string {
-	headerStyle := NewStyleBuilder().
-		WithBold().
-		WithTextColor(tcell.ColorNames["cyan"]).
-		Build()
+	headerStyle := Current.Title
 
 	styledText := ApplyStyle(text, headerStyle)
 	line := ApplyStyle(strings.Repeat("─", len(text)+4), headerStyle)
@@ -314,10 +393,7 @@ func WrapWithAction(textView *tview.TextView, text string, callback func()) stri
 	}
 
 	// Create a clickable style
-	clickableStyle := NewStyleBuilder().
-		WithTextColor(tcell.ColorBlue).
-		WithUnderline().
-		Build()
+	clickableStyle := Current.Link
 
 	return MakeClickable(text, clickableStyle, callback)
 }
@@ -328,9 +404,7 @@ func CreateListItem(textView *tview.TextView, prefix, text string, callback func
 		return prefix + " " + WrapWithAction(textView, text, callback)
 	}
 
-	itemStyle := NewStyleBuilder().
-		WithTextColor(tcell.ColorWhite).
-		Build()
+	itemStyle := Current.File
 
 	return prefix + " " + ApplyStyle(text, itemStyle)
 }
@@ -0,0 +1,341 @@
+package styling
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Theme styles the elements of the user interface
+type Theme struct {
+	Name       string
+	Background tcell.Color // Of every view, ColorDefault keeps the terminal's
+	Text       tcell.Color // Of text without a style of its own, borders and titles
+
+	Header    StyleOptions
+	Footer    StyleOptions
+	Title     StyleOptions // Section headers such as the storage status
+	Heading   StyleOptions // Column and section headings of tables, prompts
+	Highlight StyleOptions // Search matches in the tree
+	Label     StyleOptions // Labels of values
+	Link      StyleOptions // Clickable text
+	Directory StyleOptions
+	File      StyleOptions
+	Archive   StyleOptions
+	Info      StyleOptions // Scan results
+	Cached    StyleOptions // Results taken from the cache
+	Muted     StyleOptions // Secondary details such as timings
+	Warning   StyleOptions // Progress and notices
+	Error     StyleOptions
+	Success   StyleOptions
+
+	ReclaimBadge StyleOptions // Marks reclaimable directories in the tree
+	GitBadge     StyleOptions // Marks git repositories in the tree
+
+	Levels     [4]tcell.Color // Sizes and usage from low to high
+	Gradient   bool           // Colours usage on a gradient between the levels
+	Monochrome bool           // Draws without colours, see NewMonochromeScreen
+}
+
+// Current is the theme of the user interface
+var Current = DarkTheme()
+
+// Themes returns the shipped themes by name
+func Themes() map[string]func() Theme {
+	return map[string]func() Theme{
+		"dark":          DarkTheme,
+		"light":         LightTheme,
+		"high-contrast": HighContrastTheme,
+		"mono":          MonochromeTheme,
+	}
+}
+
+// ThemeNames returns the names of the shipped themes, sorted
+func ThemeNames() []string {
+	var names []string
+	for name := range Themes() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetTheme makes the named theme current, or the monochrome one if the
+// NO_COLOR environment variable is set. Call it before any view is created.
+func SetTheme(name string) error {
+	newTheme, found := Themes()[name]
+	if !found {
+		return fmt.Errorf("unknown theme %q, must be one of %v", name, ThemeNames())
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		newTheme = MonochromeTheme
+	}
+	Current = newTheme()
+	Current.applyToViews()
+	return nil
+}
+
+// styled returns the style with the given text color and optionally bold
+func styled(color tcell.Color, bold bool) StyleOptions {
+	builder := NewStyleBuilder().WithTextColor(color)
+	if bold {
+		builder.WithBold()
+	}
+	return builder.Build()
+}
+
+// badge returns the style of text in color on background
+func badge(color, background tcell.Color) StyleOptions {
+	return NewStyleBuilder().WithTextColor(color).WithBackgroundColor(background).Build()
+}
+
+// DarkTheme is the default theme, light text on black
+func DarkTheme() Theme {
+	return Theme{
+		Name:       "dark",
+		Background: tcell.ColorBlack,
+		Text:       tcell.ColorWhite,
+		Header:     styled(tcell.ColorBlue, true),
+		Footer:     styled(tcell.ColorGray, false),
+		Title:      styled(tcell.ColorAqua, true),
+		Heading:    styled(tcell.ColorYellow, true),
+		Highlight:  NewStyleBuilder().WithBackgroundColor(tcell.ColorDarkBlue).WithBold().Build(),
+		Label:      styled(tcell.ColorWhite, true),
+		Link:       NewStyleBuilder().WithTextColor(tcell.ColorBlue).WithUnderline().Build(),
+		Directory:  styled(tcell.ColorGreen, false),
+		File:       styled(tcell.ColorWhite, false),
+		Archive:    styled(tcell.ColorYellow, false),
+		Info:       styled(tcell.ColorBlue, false),
+		Cached:     styled(tcell.ColorGreen, false),
+		Muted:      styled(tcell.ColorGray, false),
+		Warning:    styled(tcell.ColorYellow, false),
+		Error:      styled(tcell.ColorRed, false),
+		Success:    styled(tcell.ColorGreen, false),
+		Levels:     [4]tcell.Color{tcell.ColorGreen, tcell.ColorYellow, tcell.ColorOrange, tcell.ColorRed},
+		Gradient:   true,
+
+		ReclaimBadge: badge(tcell.ColorBlack, tcell.ColorYellow),
+		GitBadge:     badge(tcell.ColorBlack, tcell.ColorGreen),
+	}
+}
+
+// LightTheme is dark text on white
+func LightTheme() Theme {
+	return Theme{
+		Name:       "light",
+		Background: tcell.ColorWhite,
+		Text:       tcell.ColorBlack,
+		Header:     styled(tcell.ColorNavy, true),
+		Footer:     styled(tcell.ColorDimGray, false),
+		Title:      styled(tcell.ColorTeal, true),
+		Heading:    styled(tcell.ColorDarkGoldenrod, true),
+		Highlight:  NewStyleBuilder().WithBackgroundColor(tcell.ColorLightBlue).WithBold().Build(),
+		Label:      styled(tcell.ColorBlack, true),
+		Link:       NewStyleBuilder().WithTextColor(tcell.ColorBlue).WithUnderline().Build(),
+		Directory:  styled(tcell.ColorDarkGreen, false),
+		File:       styled(tcell.ColorBlack, false),
+		Archive:    styled(tcell.ColorDarkGoldenrod, false),
+		Info:       styled(tcell.ColorNavy, false),
+		Cached:     styled(tcell.ColorDarkGreen, false),
+		Muted:      styled(tcell.ColorDimGray, false),
+		Warning:    styled(tcell.ColorDarkOrange, false),
+		Error:      styled(tcell.ColorDarkRed, false),
+		Success:    styled(tcell.ColorDarkGreen, false),
+		Levels:     [4]tcell.Color{tcell.ColorGreen, tcell.ColorGoldenrod, tcell.ColorDarkOrange, tcell.ColorRed},
+
+		ReclaimBadge: badge(tcell.ColorWhite, tcell.ColorDarkGoldenrod),
+		GitBadge:     badge(tcell.ColorWhite, tcell.ColorDarkGreen),
+	}
+}
+
+// HighContrastTheme uses only bright colours on black and bold emphasis
+func HighContrastTheme() Theme {
+	return Theme{
+		Name:       "high-contrast",
+		Background: tcell.ColorBlack,
+		Text:       tcell.ColorWhite,
+		Header:     styled(tcell.ColorWhite, true),
+		Footer:     styled(tcell.ColorWhite, false),
+		Title:      styled(tcell.ColorAqua, true),
+		Heading:    styled(tcell.ColorYellow, true),
+		Highlight:  NewStyleBuilder().WithBackgroundColor(tcell.ColorBlue).WithBold().Build(),
+		Label:      styled(tcell.ColorWhite, true),
+		Link:       NewStyleBuilder().WithTextColor(tcell.ColorAqua).WithUnderline().WithBold().Build(),
+		Directory:  styled(tcell.ColorAqua, true),
+		File:       styled(tcell.ColorWhite, false),
+		Archive:    styled(tcell.ColorYellow, true),
+		Info:       styled(tcell.ColorAqua, false),
+		Cached:     styled(tcell.ColorLime, false),
+		Muted:      styled(tcell.ColorSilver, false),
+		Warning:    styled(tcell.ColorYellow, true),
+		Error:      styled(tcell.ColorRed, true),
+		Success:    styled(tcell.ColorLime, true),
+		Levels:     [4]tcell.Color{tcell.ColorLime, tcell.ColorYellow, tcell.ColorFuchsia, tcell.ColorRed},
+
+		ReclaimBadge: badge(tcell.ColorBlack, tcell.ColorYellow),
+		GitBadge:     badge(tcell.ColorBlack, tcell.ColorLime),
+	}
+}
+
+// MonochromeTheme draws without colours, for NO_COLOR and terminals
+// without colours. Emphasis is bold or underlined, and highlights are
+// reversed.
+func MonochromeTheme() Theme {
+	plain := styled(tcell.ColorWhite, false)
+	bold := styled(tcell.ColorWhite, true)
+	// The screen reverses text on a background
+	reversed := badge(tcell.ColorBlack, tcell.ColorWhite)
+	return Theme{
+		Name:       "mono",
+		Background: tcell.ColorDefault,
+		Text:       tcell.ColorDefault,
+		Header:     bold,
+		Footer:     plain,
+		Title:      bold,
+		Heading:    bold,
+		Highlight:  NewStyleBuilder().WithTextColor(tcell.ColorWhite).WithBold().WithUnderline().Build(),
+		Label:      bold,
+		Link:       NewStyleBuilder().WithTextColor(tcell.ColorWhite).WithUnderline().Build(),
+		Directory:  bold,
+		File:       plain,
+		Archive:    NewStyleBuilder().WithTextColor(tcell.ColorWhite).WithItalic().Build(),
+		Info:       plain,
+		Cached:     plain,
+		Muted:      plain,
+		Warning:    bold,
+		Error:      bold,
+		Success:    plain,
+		Levels:     [4]tcell.Color{tcell.ColorWhite, tcell.ColorWhite, tcell.ColorWhite, tcell.ColorWhite},
+		Monochrome: true,
+
+		ReclaimBadge: reversed,
+		GitBadge:     reversed,
+	}
+}
+
+// Element returns the style of the element with the given name, as the
+// config file names them
+func (t *Theme) Element(name string) (*StyleOptions, bool) {
+	elements := map[string]*StyleOptions{
+		"header":    &t.Header,
+		"footer":    &t.Footer,
+		"title":     &t.Title,
+		"heading":   &t.Heading,
+		"highlight": &t.Highlight,
+		"label":     &t.Label,
+		"link":      &t.Link,
+		"directory": &t.Directory,
+		"file":      &t.File,
+		"archive":   &t.Archive,
+		"info":      &t.Info,
+		"cached":    &t.Cached,
+		"muted":     &t.Muted,
+		"warning":   &t.Warning,
+		"error":     &t.Error,
+		"success":   &t.Success,
+		"reclaim":   &t.ReclaimBadge,
+		"git":       &t.GitBadge,
+	}
+	style, found := elements[name]
+	return style, found
+}
+
+// applyToViews makes tview draw its views in the colours of the theme
+func (t Theme) applyToViews() {
+	tview.Styles.PrimitiveBackgroundColor = t.Background
+	tview.Styles.ContrastBackgroundColor = t.Info.TextColor
+	tview.Styles.MoreContrastBackgroundColor = t.Directory.TextColor
+	tview.Styles.BorderColor = t.Text
+	tview.Styles.TitleColor = t.Text
+	tview.Styles.GraphicsColor = t.Text
+	tview.Styles.PrimaryTextColor = t.Text
+	tview.Styles.SecondaryTextColor = t.Warning.TextColor
+	tview.Styles.TertiaryTextColor = t.Success.TextColor
+	tview.Styles.InverseTextColor = t.Info.TextColor
+	tview.Styles.ContrastSecondaryTextColor = t.Header.TextColor
+	if t.Monochrome {
+		tview.Styles.PrimaryTextColor = tcell.ColorWhite
+	}
+}
+
+// Tag returns the tview tag that starts text in style
+func (t Theme) Tag(style StyleOptions) string {
+	return styleTag(style)
+}
+
+// SizeColor returns the colour of size as a part of total
+func (t Theme) SizeColor(size, total int64) tcell.Color {
+	if total == 0 {
+		return t.File.TextColor
+	}
+
+	ratio := float64(size) / float64(total)
+	switch {
+	case ratio > 0.5:
+		return t.Levels[3]
+	case ratio > 0.25:
+		return t.Levels[2]
+	case ratio > 0.1:
+		return t.Levels[1]
+	default:
+		return t.Levels[0]
+	}
+}
+
+// UsageColor returns the colour of a partition usage in percent
+func (t Theme) UsageColor(percent float64) tcell.Color {
+	if !t.Gradient {
+		switch {
+		case percent > 90:
+			return t.Levels[3]
+		case percent > 70:
+			return t.Levels[2]
+		case percent > 50:
+			return t.Levels[1]
+		default:
+			return t.Levels[0]
+		}
+	}
+
+	switch {
+	case percent <= 50:
+		return blend(t.Levels[0], t.Levels[1], percent/50)
+	case percent <= 80:
+		return blend(t.Levels[1], t.Levels[2], (percent-50)/30)
+	default:
+		return blend(t.Levels[2], t.Levels[3], (percent-80)/20)
+	}
+}
+
+// blend mixes the colours from and to, at 0 from and at 1 to
+func blend(from, to tcell.Color, ratio float64) tcell.Color {
+	r1, g1, b1 := from.RGB()
+	r2, g2, b2 := to.RGB()
+	mix := func(a, b int32) int32 {
+		return a + int32(float64(b-a)*ratio)
+	}
+	return tcell.NewRGBColor(mix(r1, r2), mix(g1, g2), mix(b1, b2))
+}
+
+// monochromeScreen draws everything in the default colours of the terminal
+type monochromeScreen struct {
+	tcell.Screen
+}
+
+// NewMonochromeScreen wraps screen to drop all colours. Text on a coloured
+// background, such as the selection, is reversed instead.
+func NewMonochromeScreen(screen tcell.Screen) tcell.Screen {
+	return monochromeScreen{screen}
+}
+
+// SetContent draws a cell without its colours
+func (s monochromeScreen) SetContent(x, y int, primary rune, combining []rune, style tcell.Style) {
+	_, background, attributes := style.Decompose()
+	if background != tcell.ColorDefault && background != tcell.ColorBlack {
+		attributes |= tcell.AttrReverse
+	}
+	s.Screen.SetContent(x, y, primary, combining, tcell.StyleDefault.Attributes(attributes))
+}
@@ -0,0 +1,229 @@
+package web
+
+import (
+	cache "DiskSizer/Cache"
+	"DiskSizer/Utils"
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//go:embed static
+var staticFiles embed.FS
+
+// maxTreeDepth limits how many levels a single tree request returns
+const maxTreeDepth = 4
+
+// Server serves the scan of one directory as a JSON API and a single page UI
+type Server struct {
+	root          string
+	cache         *cache.DirSizeCache
+	processedSize int64
+
+	mu        sync.Mutex
+	scanning  bool
+	scanErr   error
+	scannedAt time.Time
+}
+
+// Status is the state of the scan as returned by /api/status
+type Status struct {
+	Root      string    `json:"root"`
+	Scanning  bool      `json:"scanning"`
+	Processed int64     `json:"processed"`
+	Size      int64     `json:"size"`
+	ScannedAt time.Time `json:"scannedAt"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// NewServer creates a server for the directory at root. Call
+// ScanInBackground to fill it.
+func NewServer(root string) *Server {
+	return &Server{
+		root:  root,
+		cache: cache.NewDirSizeCache(),
+	}
+}
+
+// ScanInBackground starts scanning the root directory into the cache,
+// unless a scan is already running. The previous scan is served until the
+// new one is done.
+func (s *Server) ScanInBackground() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.scanning {
+		return
+	}
+	s.scanning = true
+	atomic.StoreInt64(&s.processedSize, 0)
+	go s.scan()
+}
+
+func (s *Server) scan() {
+	entry, _, err := Utils.ScanDir(s.root, 0, 0, &s.processedSize)
+	if err == nil {
+		s.cache.Invalidate(s.root)
+		s.cache.Set(s.root, cache.FromUtilsDirEntry(entry))
+	}
+
+	s.mu.Lock()
+	s.scanning = false
+	s.scanErr = err
+	s.scannedAt = time.Now()
+	s.mu.Unlock()
+}
+
+// Handler returns the HTTP handler for the API and the UI
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/status", s.handleStatus)
+	mux.HandleFunc("GET /api/tree", s.handleTree)
+	mux.HandleFunc("GET /api/search", s.handleSearch)
+	mux.HandleFunc("POST /api/rescan", s.handleRescan)
+
+	static, _ := fs.Sub(staticFiles, "static")
+	mux.Handle("GET /", http.FileServerFS(static))
+	return mux
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	status := Status{
+		Root:      s.root,
+		Scanning:  s.scanning,
+		Processed: atomic.LoadInt64(&s.processedSize),
+		ScannedAt: s.scannedAt,
+	}
+	if s.scanErr != nil {
+		status.Error = s.scanErr.Error()
+	}
+	s.mu.Unlock()
+
+	if entry, found := s.cache.Get(s.root); found {
+		status.Size = entry.Size
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// handleTree returns the entry at ?path= (the root by default) with
+// ?depth= levels of children
+func (s *Server) handleTree(w http.ResponseWriter, r *http.Request) {
+	entry, ok := s.lookup(w, r.URL.Query().Get("path"))
+	if !ok {
+		return
+	}
+
+	depth, err := strconv.Atoi(r.URL.Query().Get("depth"))
+	if err != nil || depth < 1 {
+		depth = 1
+	}
+	writeJSON(w, http.StatusOK, trimEntry(entry, min(depth, maxTreeDepth)))
+}
+
+// handleSearch returns the entries below ?path= whose name contains ?q=,
+// largest first
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	query := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("q")))
+	if query == "" {
+		writeError(w, http.StatusBadRequest, "missing search query")
+		return
+	}
+
+	entry, ok := s.lookup(w, r.URL.Query().Get("path"))
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit < 1 {
+		limit = 100
+	}
+
+	matches := []Utils.DirEntry{}
+	var walk func(entry cache.DirEntry)
+	walk = func(entry cache.DirEntry) {
+		for _, child := range entry.Children {
+			if strings.Contains(strings.ToLower(child.Name), query) {
+				matches = append(matches, trimEntry(child, 0))
+			}
+			walk(child)
+		}
+	}
+	walk(entry)
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Size > matches[j].Size
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	writeJSON(w, http.StatusOK, matches)
+}
+
+func (s *Server) handleRescan(w http.ResponseWriter, r *http.Request) {
+	s.ScanInBackground()
+	writeJSON(w, http.StatusAccepted, map[string]string{"status": "scanning"})
+}
+
+// lookup finds the scanned entry for a path below the root and writes an
+// error response if there is none. The entry is shared with the cache, so
+// it is only read.
+func (s *Server) lookup(w http.ResponseWriter, path string) (cache.DirEntry, bool) {
+	if path == "" {
+		path = s.root
+	}
+	path = filepath.Clean(path)
+
+	rel, err := filepath.Rel(s.root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		writeError(w, http.StatusForbidden, "path is outside of "+s.root)
+		return cache.DirEntry{}, false
+	}
+
+	cachedEntry, found := s.cache.Lookup(path)
+	if !found {
+		s.mu.Lock()
+		scanning := s.scanning
+		s.mu.Unlock()
+		if scanning {
+			writeError(w, http.StatusServiceUnavailable, "scan in progress")
+		} else {
+			writeError(w, http.StatusNotFound, "path not found: "+path)
+		}
+		return cache.DirEntry{}, false
+	}
+	return cachedEntry, true
+}
+
+// trimEntry converts a cached entry with depth levels of children, without
+// copying the levels below them
+func trimEntry(entry cache.DirEntry, depth int) Utils.DirEntry {
+	children := entry.Children
+	entry.Children = nil
+	trimmed := cache.ToUtilsDirEntry(entry)
+	if depth <= 0 {
+		return trimmed
+	}
+	for _, child := range children {
+		trimmed.Children = append(trimmed.Children, trimEntry(child, depth-1))
+	}
+	return trimmed
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}